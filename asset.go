@@ -0,0 +1,34 @@
+package zen
+
+import "fmt"
+
+// AssetResolver resolve names of static assets, e.g. "app.js", to their public URLs
+type AssetResolver interface {
+	AssetURL(name string) (string, bool)
+}
+
+// SetAssetResolver return Option for set the AssetResolver used by AssetURL and the asset template func
+func SetAssetResolver(resolver AssetResolver) Option {
+	return func(s *Server) {
+		s.assetResolver = resolver
+	}
+}
+
+// AssetURL return the public URL of asset name, false is returned if
+// the asset is unknown or server has no AssetResolver
+func (s *Server) AssetURL(name string) (string, bool) {
+	if s.assetResolver == nil {
+		return "", false
+	}
+	return s.assetResolver.AssetURL(name)
+}
+
+// assetURL return the public URL of asset name for the asset template func
+func (ctx *Context) assetURL(name string) (string, error) {
+	if ctx.server != nil {
+		if url, ok := ctx.server.AssetURL(name); ok {
+			return url, nil
+		}
+	}
+	return "", fmt.Errorf("zen: unknown asset %q", name)
+}
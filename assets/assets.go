@@ -0,0 +1,257 @@
+// Package assets serve static files at content-hashed URLs for cache busting
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/philchia/zen"
+)
+
+// ImmutableCacheControl is the Cache-Control of assets served at hashed URLs
+const ImmutableCacheControl = "public, max-age=31536000, immutable"
+
+// DefaultHashLength is the default number of hex characters of hashes in asset names
+const DefaultHashLength = 10
+
+// StalePolicy decide how requests for outdated hashed names are answered
+type StalePolicy uint8
+
+const (
+	// StaleRedirect redirect to the current hashed URL
+	StaleRedirect StalePolicy = iota
+	// StaleNotFound answer 404
+	StaleNotFound
+)
+
+// Option customize Assets
+type Option func(*Assets)
+
+// SetPrefix return Option for set the URL prefix assets are served under, default is "/assets"
+func SetPrefix(prefix string) Option {
+	return func(a *Assets) {
+		a.prefix = "/" + strings.Trim(prefix, "/")
+	}
+}
+
+// SetManifest return Option for load hashed names from a json manifest file of fsys
+// which map names to hashed names, e.g. {"app.js": "app.3f2a9c1b04.js"}, instead of
+// hashing files at startup. Files are served from their hashed names.
+func SetManifest(name string) Option {
+	return func(a *Assets) {
+		a.manifest = name
+	}
+}
+
+// SetStalePolicy return Option for set how requests for outdated hashes are answered, default is StaleRedirect
+func SetStalePolicy(policy StalePolicy) Option {
+	return func(a *Assets) {
+		a.stale = policy
+	}
+}
+
+// SetHashLength return Option for set the number of hex characters of hashes, from 1 to 64
+func SetHashLength(n int) Option {
+	return func(a *Assets) {
+		a.hashLength = n
+	}
+}
+
+// Assets map names of static files to content-hashed names
+type Assets struct {
+	fsys       fs.FS
+	prefix     string
+	manifest   string
+	stale      StalePolicy
+	hashLength int
+
+	// hashed map names to hashed names
+	hashed map[string]string
+	// files map hashed names to the files served for them
+	files map[string]string
+}
+
+// New create Assets of files in dir
+func New(dir string, options ...Option) (*Assets, error) {
+	return NewFS(os.DirFS(dir), options...)
+}
+
+// NewFS create Assets of files in fsys, files are hashed unless a manifest is set
+func NewFS(fsys fs.FS, options ...Option) (*Assets, error) {
+	a := &Assets{
+		fsys:       fsys,
+		prefix:     "/assets",
+		hashLength: DefaultHashLength,
+		hashed:     make(map[string]string),
+		files:      make(map[string]string),
+	}
+	for _, option := range options {
+		option(a)
+	}
+	if a.hashLength < 1 || a.hashLength > sha256.Size*2 {
+		return nil, fmt.Errorf("assets: invalid hash length %d, must be from 1 to %d", a.hashLength, sha256.Size*2)
+	}
+
+	if a.manifest != "" {
+		return a, a.loadManifest()
+	}
+	return a, a.hashFiles()
+}
+
+// loadManifest read hashed names from the manifest
+func (a *Assets) loadManifest() error {
+	data, err := fs.ReadFile(a.fsys, a.manifest)
+	if err != nil {
+		return err
+	}
+	var manifest map[string]string
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("assets: invalid manifest %s: %w", a.manifest, err)
+	}
+	for name, hashed := range manifest {
+		name, hashed = strings.TrimPrefix(name, "/"), strings.TrimPrefix(hashed, "/")
+		a.hashed[name] = hashed
+		a.files[hashed] = hashed
+	}
+	return nil
+}
+
+// hashFiles hash every file of fsys, files and directories whose name begin with a dot are skipped
+func (a *Assets) hashFiles() error {
+	return fs.WalkDir(a.fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != "." && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		f, err := a.fsys.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return err
+		}
+		hashed := hashedName(p, hex.EncodeToString(h.Sum(nil))[:a.hashLength])
+		a.hashed[p] = hashed
+		a.files[hashed] = p
+		return nil
+	})
+}
+
+// hashedName insert hash before the extension of name
+func hashedName(name, hash string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+// unhashedName remove a hex hash before the extension of name, if any,
+// and return the name without it and the length of the hash
+func unhashedName(name string) (string, int, bool) {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	i := strings.LastIndexByte(base, '.')
+	if i < 0 || i == len(base)-1 {
+		return "", 0, false
+	}
+	// hashes of odd length are not decodable, check the digits
+	for _, c := range base[i+1:] {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return "", 0, false
+		}
+	}
+	return base[:i] + ext, len(base) - i - 1, true
+}
+
+// AssetURL return the hashed URL of name, e.g. "/assets/app.3f2a9c1b04.js" for "app.js"
+func (a *Assets) AssetURL(name string) (string, bool) {
+	hashed, ok := a.hashed[strings.TrimPrefix(name, "/")]
+	if !ok {
+		return "", false
+	}
+	return a.prefix + "/" + hashed, true
+}
+
+// URL return the hashed URL of name, or an error if name is unknown
+func (a *Assets) URL(name string) (string, error) {
+	url, ok := a.AssetURL(name)
+	if !ok {
+		return "", fmt.Errorf("assets: unknown asset %q", name)
+	}
+	return url, nil
+}
+
+// FuncMap return template funcs with asset, for renderers other than zen.HTMLRenderer
+func (a *Assets) FuncMap() template.FuncMap {
+	return template.FuncMap{"asset": a.URL}
+}
+
+// WriteManifest write the json manifest of hashed names, to be loaded with SetManifest
+func (a *Assets) WriteManifest(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(a.hashed)
+}
+
+// Names return names of all assets in order
+func (a *Assets) Names() []string {
+	names := make([]string, 0, len(a.hashed))
+	for name := range a.hashed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Register serve assets under prefix of server, and set assets as the AssetResolver of server
+func (a *Assets) Register(s *zen.Server) {
+	zen.SetAssetResolver(a)(s)
+	s.Get(a.prefix+"/*filepath", a.serve)
+}
+
+// serve answer a request for an asset. Hashed names are served with immutable caching,
+// plain names are served without caching, and outdated hashes are answered by stale policy.
+func (a *Assets) serve(ctx zen.Context) {
+	name := strings.TrimPrefix(ctx.Param("filepath"), "/")
+
+	if file, ok := a.files[name]; ok {
+		ctx.Rw.Header().Set(zen.HeaderCacheControl, ImmutableCacheControl)
+		ctx.FileFS(a.fsys, file)
+		return
+	}
+	if _, ok := a.hashed[name]; ok && a.manifest == "" {
+		ctx.Rw.Header().Set(zen.HeaderCacheControl, "no-cache")
+		ctx.FileFS(a.fsys, name)
+		return
+	}
+
+	// hashes of a manifest may have another length than hashLength,
+	// so name is outdated if its hash is as long as the current one
+	if original, n, ok := unhashedName(name); ok && a.stale == StaleRedirect {
+		if url, ok := a.AssetURL(original); ok && len(a.hashed[original]) == len(original)+n+1 {
+			ctx.Rw.Header().Set(zen.HeaderCacheControl, "no-cache")
+			ctx.Rw.Header().Set(zen.HeaderLocation, url)
+			ctx.WriteStatus(zen.StatusFound)
+			return
+		}
+	}
+	ctx.Error(zen.NewHTTPError(zen.StatusNotFound, ""))
+}
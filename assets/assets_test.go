@@ -0,0 +1,166 @@
+package assets
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/philchia/zen"
+)
+
+func TestAssets(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.js":        {Data: []byte("console.log(1)")},
+		"css/style.css": {Data: []byte("body{}")},
+		".env":          {Data: []byte("SECRET=1")},
+		".git/config":   {Data: []byte("[core]")},
+	}
+	a, err := NewFS(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appURL, ok := a.AssetURL("app.js")
+	if !ok || !strings.HasPrefix(appURL, "/assets/app.") || !strings.HasSuffix(appURL, ".js") || len(appURL) != len("/assets/app..js")+DefaultHashLength {
+		t.Fatalf("Assets.AssetURL() = %q, %v", appURL, ok)
+	}
+	if _, err := a.URL("missing.js"); err == nil {
+		t.Error("Assets.URL() error = nil for unknown asset")
+	}
+
+	tests := []struct {
+		name         string
+		policy       StalePolicy
+		path         string
+		code         int
+		body         string
+		cacheControl string
+		location     string
+	}{
+		{"hashed", StaleRedirect, appURL, zen.StatusOK, "console.log(1)", ImmutableCacheControl, ""},
+		{"plain", StaleRedirect, "/assets/css/style.css", zen.StatusOK, "body{}", "no-cache", ""},
+		{"stale redirect", StaleRedirect, "/assets/app.0123456789.js", zen.StatusFound, "", "no-cache", appURL},
+		{"stale not found", StaleNotFound, "/assets/app.0123456789.js", zen.StatusNotFound, "", "", ""},
+		{"unknown", StaleRedirect, "/assets/missing.0123456789.js", zen.StatusNotFound, "", "", ""},
+		{"dotfile", StaleRedirect, "/assets/.env", zen.StatusNotFound, "", "", ""},
+		{"dot directory", StaleRedirect, "/assets/.git/config", zen.StatusNotFound, "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, _ := NewFS(fsys, SetStalePolicy(tt.policy))
+			server := zen.New()
+			a.Register(server)
+
+			rw := httptest.NewRecorder()
+			server.ServeHTTP(rw, httptest.NewRequest("GET", tt.path, nil))
+			if rw.Code != tt.code {
+				t.Fatalf("GET %s code = %d, want %d", tt.path, rw.Code, tt.code)
+			}
+			if tt.body != "" && rw.Body.String() != tt.body {
+				t.Errorf("GET %s body = %q, want %q", tt.path, rw.Body.String(), tt.body)
+			}
+			if cc := rw.Header().Get(zen.HeaderCacheControl); cc != tt.cacheControl {
+				t.Errorf("GET %s Cache-Control = %q, want %q", tt.path, cc, tt.cacheControl)
+			}
+			if location := rw.Header().Get(zen.HeaderLocation); location != tt.location {
+				t.Errorf("GET %s Location = %q, want %q", tt.path, location, tt.location)
+			}
+		})
+	}
+}
+
+func TestNewFS_hashLength(t *testing.T) {
+	fsys := fstest.MapFS{"app.js": {Data: []byte("console.log(1)")}}
+	tests := []struct {
+		n       int
+		wantErr bool
+	}{
+		{-1, true},
+		{0, true},
+		{1, false},
+		{64, false},
+		{65, true},
+	}
+	for _, tt := range tests {
+		a, err := NewFS(fsys, SetHashLength(tt.n))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewFS(SetHashLength(%d)) error = %v, wantErr %v", tt.n, err, tt.wantErr)
+			continue
+		}
+		if err == nil {
+			if url, _ := a.AssetURL("app.js"); len(url) != len("/assets/app..js")+tt.n {
+				t.Errorf("NewFS(SetHashLength(%d)) AssetURL() = %q", tt.n, url)
+			}
+		}
+	}
+}
+
+func TestAssets_manifest(t *testing.T) {
+	hashed, _ := NewFS(fstest.MapFS{"app.js": {Data: []byte("v1")}})
+	var manifest bytes.Buffer
+	if err := hashed.WriteManifest(&manifest); err != nil {
+		t.Fatal(err)
+	}
+	url, _ := hashed.AssetURL("app.js")
+	file := strings.TrimPrefix(url, "/assets/")
+
+	fsys := fstest.MapFS{
+		"manifest.json": {Data: manifest.Bytes()},
+		file:            {Data: []byte("v1")},
+	}
+	a, err := NewFS(fsys, SetManifest("manifest.json"), SetPrefix("/static/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := a.AssetURL("app.js"); got != "/static/"+file {
+		t.Errorf("Assets.AssetURL() = %q, want %q", got, "/static/"+file)
+	}
+
+	renderer, err := zen.NewHTMLRendererFS(fstest.MapFS{"index.html": {Data: []byte(`<script src="{{asset "app.js"}}"></script>`)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := zen.New(zen.SetRenderer(renderer))
+	a.Register(server)
+	server.Get("/page", func(ctx zen.Context) {
+		if err := ctx.HTML(zen.StatusOK, "index", nil); err != nil {
+			ctx.Error(err)
+		}
+	})
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/page", `<script src="/static/` + file + `"></script>`},
+		{"/static/" + file, "v1"},
+	}
+	for _, tt := range tests {
+		rw := httptest.NewRecorder()
+		server.ServeHTTP(rw, httptest.NewRequest("GET", tt.path, nil))
+		if rw.Code != zen.StatusOK || rw.Body.String() != tt.body {
+			t.Errorf("GET %s = %d %q, want %q", tt.path, rw.Code, rw.Body.String(), tt.body)
+		}
+	}
+
+	// hashes of the manifest are shorter than DefaultHashLength
+	short, err := NewFS(fstest.MapFS{
+		"manifest.json":   {Data: []byte(`{"app.js": "app.3f2a9c1b.js"}`)},
+		"app.3f2a9c1b.js": {Data: []byte("v2")},
+	}, SetManifest("manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server = zen.New()
+	short.Register(server)
+	rw := httptest.NewRecorder()
+	server.ServeHTTP(rw, httptest.NewRequest("GET", "/assets/app.0e1d2c3b.js", nil))
+	if rw.Code != zen.StatusFound || rw.Header().Get(zen.HeaderLocation) != "/assets/app.3f2a9c1b.js" {
+		t.Errorf("GET stale asset = %d %q, want redirect to current hash", rw.Code, rw.Header().Get(zen.HeaderLocation))
+	}
+
+	if _, err := NewFS(fstest.MapFS{}, SetManifest("manifest.json")); err == nil {
+		t.Error("NewFS() error = nil for missing manifest")
+	}
+}
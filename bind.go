@@ -0,0 +1,188 @@
+package zen
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// BindErrorKind describe why binding request body failed
+type BindErrorKind uint32
+
+const (
+	// BindSyntax body is not well formed
+	BindSyntax BindErrorKind = iota
+	// BindType body value can not be stored into target's field
+	BindType
+	// BindUnknownField body contains a field not exist in target, strict mode only
+	BindUnknownField
+	// BindTrailingData body contains data after the first value, strict mode only
+	BindTrailingData
+	// BindTooLarge body exceed the size limit
+	BindTooLarge
+	// BindEmpty body is empty
+	BindEmpty
+)
+
+func (k BindErrorKind) String() string {
+	switch k {
+	case BindSyntax:
+		return "syntax"
+	case BindType:
+		return "type"
+	case BindUnknownField:
+		return "unknownfield"
+	case BindTrailingData:
+		return "trailingdata"
+	case BindTooLarge:
+		return "toolarge"
+	case BindEmpty:
+		return "empty"
+	}
+
+	return ""
+}
+
+// BindError is returned by BindJSON and BindXML when request body can not be bound
+type BindError struct {
+	Kind BindErrorKind
+	// Field is the field path which cause the error, if known
+	Field string
+	// Offset is the input offset where the error occurred, if known
+	Offset int64
+	// Err is the underlying decoder error
+	Err error
+}
+
+func (e *BindError) Error() string {
+	switch {
+	case e.Field != "":
+		return fmt.Sprintf("zen: bind %s error on field %q: %v", e.Kind, e.Field, e.Err)
+	case e.Offset > 0:
+		return fmt.Sprintf("zen: bind %s error at offset %d: %v", e.Kind, e.Offset, e.Err)
+	}
+	return fmt.Sprintf("zen: bind %s error: %v", e.Kind, e.Err)
+}
+
+// Unwrap return the underlying decoder error
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode return the http status code which should be answered for this error
+func (e *BindError) StatusCode() int {
+	if e.Kind == BindTooLarge {
+		return StatusRequestEntityTooLarge
+	}
+	return StatusBadRequest
+}
+
+var errTrailingData = errors.New("unexpected data after top-level value")
+
+// newBindError classify decoder error into BindError,
+// errors which are not caused by request body are returned as is
+func newBindError(err error) error {
+	var (
+		tooLarge    *http.MaxBytesError
+		syntaxErr   *json.SyntaxError
+		typeErr     *json.UnmarshalTypeError
+		xmlSyntax   *xml.SyntaxError
+		numErr      *strconv.NumError
+		alreadyBind *BindError
+	)
+
+	switch {
+	case errors.As(err, &alreadyBind):
+		return err
+	case errors.As(err, &tooLarge):
+		return &BindError{Kind: BindTooLarge, Err: err}
+	case err == io.EOF:
+		return &BindError{Kind: BindEmpty, Err: err}
+	case err == io.ErrUnexpectedEOF:
+		return &BindError{Kind: BindSyntax, Err: err}
+	case err == errTrailingData:
+		return &BindError{Kind: BindTrailingData, Err: err}
+	case errors.As(err, &syntaxErr):
+		return &BindError{Kind: BindSyntax, Offset: syntaxErr.Offset, Err: err}
+	case errors.As(err, &typeErr):
+		return &BindError{Kind: BindType, Field: typeErr.Field, Offset: typeErr.Offset, Err: err}
+	case errors.As(err, &xmlSyntax):
+		return &BindError{Kind: BindSyntax, Err: err}
+	case errors.As(err, &numErr):
+		return &BindError{Kind: BindType, Err: err}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+		return &BindError{Kind: BindUnknownField, Field: field, Err: err}
+	}
+	return err
+}
+
+// decodeJSON decode json from r into input, in strict mode unknown fields and
+// trailing data are rejected, and numbers are decoded as json.Number
+func decodeJSON(r io.Reader, input interface{}, strict bool) error {
+	decoder := json.NewDecoder(r)
+	if strict {
+		decoder.DisallowUnknownFields()
+		decoder.UseNumber()
+	}
+
+	if err := decoder.Decode(input); err != nil {
+		return newBindError(err)
+	}
+
+	if strict {
+		if _, err := decoder.Token(); err != io.EOF {
+			if err == nil {
+				err = errTrailingData
+			}
+			return newBindError(err)
+		}
+	}
+	return nil
+}
+
+// strictJSON report whether BindJSON should decode in strict mode
+func (ctx *Context) strictJSON() bool {
+	return ctx.server != nil && ctx.server.StrictJSON
+}
+
+// limitedBody is a request body limited by http.MaxBytesReader,
+// it keeps the original body so the limit can be replaced
+type limitedBody struct {
+	io.ReadCloser
+	orig io.ReadCloser
+}
+
+// limitBody limit request's body to n bytes, replace any limit set before
+func (ctx *Context) limitBody(n int64) {
+	orig := ctx.Req.Body
+	if lb, ok := orig.(*limitedBody); ok {
+		orig = lb.orig
+	}
+	ctx.Req.Body = &limitedBody{
+		ReadCloser: http.MaxBytesReader(baseWriter(ctx.Rw), orig, n),
+		orig:       orig,
+	}
+}
+
+// BodyLimit return a Middleware which limit request body of a route to n bytes,
+// it replace the server-wide MaxBodyBytes. Requests whose Content-Length exceed
+// n are answered with 413 Request Entity Too Large before calling the handler,
+// bodies without Content-Length will fail to bind with a BindTooLarge error.
+func BodyLimit(n int64) Middleware {
+	return func(h HandlerFunc) HandlerFunc {
+		return func(ctx Context) {
+			if ctx.Req.ContentLength > n {
+				ctx.Error(NewHTTPError(StatusRequestEntityTooLarge, ""))
+				return
+			}
+			ctx.limitBody(n)
+			h(ctx)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package zen
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestContext_BindJSONErrors(t *testing.T) {
+	type input struct {
+		Name string      `json:"name"`
+		Age  int         `json:"age"`
+		Num  interface{} `json:"num"`
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		strict bool
+		limit  int64
+		kind   BindErrorKind
+		ok     bool
+	}{
+		{"ok", `{"name":"zen","age":1}`, false, 0, 0, true},
+		{"unknown field loose", `{"name":"zen","nope":1}`, false, 0, 0, true},
+		{"trailing loose", `{"name":"zen"} {}`, false, 0, 0, true},
+		{"syntax", `{"name":`, false, 0, BindSyntax, false},
+		{"bad syntax", `{"name" 1}`, false, 0, BindSyntax, false},
+		{"type", `{"age":"one"}`, false, 0, BindType, false},
+		{"empty", ``, false, 0, BindEmpty, false},
+		{"unknown field strict", `{"name":"zen","nope":1}`, true, 0, BindUnknownField, false},
+		{"trailing strict", `{"name":"zen"} {}`, true, 0, BindTrailingData, false},
+		{"too large", `{"name":"zenzenzenzenzenzenzen"}`, false, 8, BindTooLarge, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := New(SetStrictJSON(tt.strict), SetMaxBodyBytes(tt.limit))
+			var err error
+			server.Post("/json", func(ctx Context) {
+				var in input
+				err = ctx.BindJSON(&in)
+			})
+			server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/json", strings.NewReader(tt.body)))
+
+			if tt.ok {
+				if err != nil {
+					t.Errorf("Context.BindJSON() error = %v, want nil", err)
+				}
+				return
+			}
+			var bindErr *BindError
+			if !errors.As(err, &bindErr) {
+				t.Fatalf("Context.BindJSON() error = %v, want *BindError", err)
+			}
+			if bindErr.Kind != tt.kind {
+				t.Errorf("Context.BindJSON() error kind = %s, want %s", bindErr.Kind, tt.kind)
+			}
+		})
+	}
+}
+
+func TestContext_BindJSONStrictNumber(t *testing.T) {
+	server := New(SetStrictJSON(true))
+	var in map[string]interface{}
+	server.Post("/json", func(ctx Context) {
+		ctx.BindJSON(&in)
+	})
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/json", strings.NewReader(`{"num":10086}`)))
+
+	if _, ok := in["num"].(json.Number); !ok {
+		t.Errorf("Context.BindJSON() strict decode number into %T, want json.Number", in["num"])
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	server := New(SetMaxBodyBytes(4))
+	var err error
+	server.Post("/small", func(ctx Context) {
+		var in map[string]string
+		err = ctx.BindJSON(&in)
+	})
+	server.Post("/large", BodyLimit(64)(func(ctx Context) {
+		var in map[string]string
+		err = ctx.BindJSON(&in)
+	}))
+	server.Post("/tiny", BodyLimit(1)(func(ctx Context) {}))
+
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/large", strings.NewReader(`{"name":"zen"}`)))
+	if err != nil {
+		t.Errorf("BodyLimit() override server limit, got error %v", err)
+	}
+
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/small", strings.NewReader(`{"name":"zen"}`)))
+	if bindErr, ok := err.(*BindError); !ok || bindErr.StatusCode() != StatusRequestEntityTooLarge {
+		t.Errorf("MaxBodyBytes got error %v, want BindTooLarge", err)
+	}
+
+	rw := httptest.NewRecorder()
+	server.ServeHTTP(rw, httptest.NewRequest("POST", "/tiny", strings.NewReader(`{"name":"zen"}`)))
+	if rw.Code != StatusRequestEntityTooLarge {
+		t.Errorf("BodyLimit() code = %d, want %d", rw.Code, StatusRequestEntityTooLarge)
+	}
+}
@@ -0,0 +1,100 @@
+package zen
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// ErrBodyNotCached is returned by Context.Body when the route does not use CacheBody
+var ErrBodyNotCached = errors.New("zen: request body cache is not enabled, use CacheBody")
+
+type bodyCacheKey struct{}
+
+// bodyCache hold the raw request body of a route using CacheBody
+type bodyCache struct {
+	data []byte
+	err  error
+}
+
+// cachedBody replace request's body once it is cached, it can be rewound
+// so every binder or wrapped http.HandlerFunc read the whole body
+type cachedBody struct {
+	*bytes.Reader
+}
+
+// Close implement io.Closer
+func (b *cachedBody) Close() error {
+	return nil
+}
+
+// uncachedBody replace request's body which is not cached, so the part read
+// by CacheBody is still read by handler before the rest of the body
+type uncachedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// CacheBody return a Middleware which enable Context.Body for a route,
+// at most limit bytes of the request body are read and cached in memory
+// before calling handler, so it does not matter who read the body first.
+// It is opt-in to avoid buffering large uploads in memory.
+func CacheBody(limit int64) Middleware {
+	return func(h HandlerFunc) HandlerFunc {
+		return func(ctx Context) {
+			ctx.SetValue(bodyCacheKey{}, ctx.cacheBody(limit))
+			h(ctx)
+		}
+	}
+}
+
+// Body return request's raw body cached by CacheBody, the body is rewound so it
+// can be read again by other middleware, binders and http.HandlerFunc. Bodies
+// larger than the limit set by CacheBody are rejected with a BindTooLarge error,
+// and they are left for handler to read in whole.
+// It returns ErrBodyNotCached if the route does not use CacheBody.
+func (ctx *Context) Body() ([]byte, error) {
+	cache, ok := ctx.Value(bodyCacheKey{}).(*bodyCache)
+	if !ok {
+		return nil, ErrBodyNotCached
+	}
+	if cache.err != nil {
+		return nil, cache.err
+	}
+
+	ctx.rewindBody()
+	return cache.data, nil
+}
+
+// rewindBody rewind cached request body, so it can be read from the beginning again
+func (ctx *Context) rewindBody() {
+	if body, ok := ctx.Req.Body.(*cachedBody); ok {
+		body.Seek(0, io.SeekStart)
+	}
+}
+
+// cacheBody read at most limit bytes of request's body and replace the body with the cache
+func (ctx *Context) cacheBody(limit int64) *bodyCache {
+	body := ctx.Req.Body
+	if body == nil || body == http.NoBody {
+		return &bodyCache{data: []byte{}}
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(body, limit+1))
+	if err == nil && int64(len(data)) > limit {
+		err = &BindError{Kind: BindTooLarge, Err: errors.New("request body exceed cache limit")}
+	}
+	if err != nil {
+		ctx.Req.Body = &uncachedBody{io.MultiReader(bytes.NewReader(data), body), body}
+		return &bodyCache{err: newBindError(err)}
+	}
+
+	body.Close()
+	ctx.Req.Body = &cachedBody{bytes.NewReader(data)}
+	ctx.Req.GetBody = func() (io.ReadCloser, error) {
+		return &cachedBody{bytes.NewReader(data)}, nil
+	}
+	return &bodyCache{data: data}
+}
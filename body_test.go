@@ -0,0 +1,101 @@
+package zen
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestContext_Body(t *testing.T) {
+	server := New()
+
+	var (
+		signed, raw string
+		input       struct {
+			Name string `json:"name"`
+		}
+	)
+	verify := func(h HandlerFunc) HandlerFunc {
+		return func(ctx Context) {
+			body, err := ctx.Body()
+			if err != nil {
+				t.Fatalf("Context.Body() error = %v", err)
+			}
+			signed = string(body)
+			h(ctx)
+		}
+	}
+	server.Post("/cached", CacheBody(1024)(verify(func(ctx Context) {
+		if err := ctx.BindJSON(&input); err != nil {
+			t.Errorf("Context.BindJSON() after Body() error = %v", err)
+		}
+		WrapF(func(rw http.ResponseWriter, req *http.Request) {
+			data, _ := ioutil.ReadAll(req.Body)
+			raw = string(data)
+		})(ctx)
+	})))
+
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/cached", strings.NewReader(`{"name":"zen"}`)))
+	if signed != `{"name":"zen"}` {
+		t.Errorf("Context.Body() = %s, want %s", signed, `{"name":"zen"}`)
+	}
+	if input.Name != "zen" {
+		t.Errorf("Context.BindJSON() name = %s, want %s", input.Name, "zen")
+	}
+	if raw != `{"name":"zen"}` {
+		t.Errorf("WrapF read body %s, want %s", raw, `{"name":"zen"}`)
+	}
+}
+
+func TestContext_BodyAfterBind(t *testing.T) {
+	var logged string
+	logBody := func(h HandlerFunc) HandlerFunc {
+		return func(ctx Context) {
+			h(ctx)
+			body, _ := ctx.Body()
+			logged = string(body)
+		}
+	}
+	server := New()
+	server.Post("/items", CacheBody(1024)(logBody(func(ctx Context) {
+		var input struct {
+			Name string `json:"name"`
+		}
+		if err := ctx.BindJSON(&input); err != nil {
+			t.Errorf("Context.BindJSON() error = %v", err)
+		}
+	})))
+
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/items", strings.NewReader(`{"name":"zen"}`)))
+	if logged != `{"name":"zen"}` {
+		t.Errorf("Context.Body() after BindJSON = %q, want %q", logged, `{"name":"zen"}`)
+	}
+}
+
+func TestContext_BodyNotCached(t *testing.T) {
+	ctx := getContext(httptest.NewRecorder(), httptest.NewRequest("POST", "/", strings.NewReader("zen")))
+	if _, err := ctx.Body(); err != ErrBodyNotCached {
+		t.Errorf("Context.Body() error = %v, want %v", err, ErrBodyNotCached)
+	}
+}
+
+func TestContext_BodyTooLarge(t *testing.T) {
+	var (
+		err  error
+		read []byte
+	)
+	handler := CacheBody(2)(func(ctx Context) {
+		_, err = ctx.Body()
+		read, _ = ioutil.ReadAll(ctx.Req.Body)
+	})
+	handler(getContext(httptest.NewRecorder(), httptest.NewRequest("POST", "/", strings.NewReader("zen"))))
+
+	if bindErr, ok := err.(*BindError); !ok || bindErr.Kind != BindTooLarge {
+		t.Errorf("Context.Body() error = %v, want BindTooLarge", err)
+	}
+	if string(read) != "zen" {
+		t.Errorf("read body = %q, want %q", read, "zen")
+	}
+}
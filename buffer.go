@@ -0,0 +1,213 @@
+package zen
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// ensure responseBuffer implement ResponseBuffer
+var (
+	_ ResponseBuffer = (*responseBuffer)(nil)
+)
+
+// ResponseBuffer is a ResponseWriter which hold the response in memory until
+// it is committed, so middleware running after the handler can still inspect
+// and rewrite the header, status and body. Once the body exceed the limit, or
+// the response is flushed, the buffer switches to streaming.
+type ResponseBuffer interface {
+	ResponseWriter
+
+	// Buffered report whether the response is still held in memory
+	Buffered() bool
+
+	// Bytes return the buffered body
+	Bytes() []byte
+
+	// SetStatus replace the buffered status code
+	SetStatus(code int)
+
+	// Reset discard the buffered body, header and status are kept
+	Reset()
+
+	// Commit send the buffered response to the underlying writer and switch to streaming
+	Commit()
+}
+
+type responseBuffer struct {
+	rw        ResponseWriter
+	limit     int
+	status    int
+	body      bytes.Buffer
+	streaming bool
+}
+
+// newResponseBuffer return a ResponseBuffer which hold at most limit bytes of body before streaming
+func newResponseBuffer(rw ResponseWriter, limit int) *responseBuffer {
+	return &responseBuffer{rw: rw, limit: limit}
+}
+
+// BufferResponse return a Middleware which buffer the response of a route in memory,
+// at most limit bytes of body are buffered before switching to streaming.
+// Middleware between BufferResponse and the handler can get the buffer from
+// Context.ResponseBuffer after calling the handler, and rewrite the response.
+// A middleware can also wrap its next handler with BufferResponse to buffer
+// only for itself.
+func BufferResponse(limit int) Middleware {
+	return func(h HandlerFunc) HandlerFunc {
+		return func(ctx Context) {
+			buf := newResponseBuffer(ctx.Response(), limit)
+			ctx.Rw = buf
+			h(ctx)
+			// not committed if h panics, the response is answered by the recovery
+			buf.Commit()
+		}
+	}
+}
+
+// discardBuffers drop the status and body held by the response buffers of rw
+func discardBuffers(rw http.ResponseWriter) {
+	for {
+		if b, ok := rw.(*responseBuffer); ok && !b.streaming {
+			b.status = 0
+			b.body.Reset()
+		}
+		w, ok := rw.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return
+		}
+		rw = w.Unwrap()
+	}
+}
+
+// ResponseBuffer return the ResponseBuffer of the context, if the route use BufferResponse
+func (ctx *Context) ResponseBuffer() (ResponseBuffer, bool) {
+	buf, ok := ctx.Rw.(ResponseBuffer)
+	return buf, ok
+}
+
+func (b *responseBuffer) Header() http.Header {
+	return b.rw.Header()
+}
+
+func (b *responseBuffer) WriteHeader(code int) {
+	if b.streaming {
+		b.rw.WriteHeader(code)
+		return
+	}
+	// informational headers are not buffered
+	if code >= 100 && code < 200 && code != StatusSwitchingProtocols {
+		b.rw.WriteHeader(code)
+		return
+	}
+	if b.status == 0 {
+		b.status = code
+	}
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	if b.streaming {
+		return b.rw.Write(p)
+	}
+	if b.status == 0 {
+		b.status = StatusOK
+	}
+	if b.body.Len()+len(p) > b.limit {
+		b.Commit()
+		return b.rw.Write(p)
+	}
+	return b.body.Write(p)
+}
+
+func (b *responseBuffer) WriteString(s string) (int, error) {
+	return b.Write([]byte(s))
+}
+
+func (b *responseBuffer) ReadFrom(r io.Reader) (int64, error) {
+	if b.streaming {
+		return b.rw.ReadFrom(r)
+	}
+	// hide ReadFrom so io.Copy use Write
+	return io.Copy(struct{ io.Writer }{b}, r)
+}
+
+func (b *responseBuffer) Flush() {
+	b.Commit()
+	b.rw.Flush()
+}
+
+func (b *responseBuffer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	b.streaming = true
+	return b.rw.Hijack()
+}
+
+func (b *responseBuffer) Push(target string, opts *http.PushOptions) error {
+	return b.rw.Push(target, opts)
+}
+
+func (b *responseBuffer) Status() int {
+	if b.streaming {
+		return b.rw.Status()
+	}
+	return b.status
+}
+
+func (b *responseBuffer) Size() int {
+	if b.streaming {
+		return b.rw.Size()
+	}
+	return b.body.Len()
+}
+
+func (b *responseBuffer) Written() bool {
+	return b.Status() != 0
+}
+
+func (b *responseBuffer) Before(fn func(ResponseWriter)) {
+	b.rw.Before(fn)
+}
+
+func (b *responseBuffer) Unwrap() http.ResponseWriter {
+	return b.rw
+}
+
+func (b *responseBuffer) Buffered() bool {
+	return !b.streaming
+}
+
+func (b *responseBuffer) Bytes() []byte {
+	return b.body.Bytes()
+}
+
+func (b *responseBuffer) SetStatus(code int) {
+	if !b.streaming {
+		b.status = code
+	}
+}
+
+func (b *responseBuffer) Reset() {
+	b.body.Reset()
+}
+
+func (b *responseBuffer) Commit() {
+	if b.streaming {
+		return
+	}
+	b.streaming = true
+
+	// the body may be rewritten after Content-Length is set, an empty body
+	// keep it for responses to HEAD requests
+	if b.body.Len() > 0 && b.Header().Get(HeaderContentLength) != "" {
+		b.Header().Set(HeaderContentLength, strconv.Itoa(b.body.Len()))
+	}
+	if b.status != 0 {
+		b.rw.WriteHeader(b.status)
+	}
+	if b.body.Len() > 0 {
+		b.rw.Write(b.body.Bytes())
+		b.body.Reset()
+	}
+}
@@ -0,0 +1,88 @@
+package zen
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBufferResponse(t *testing.T) {
+	rewrite := func(h HandlerFunc) HandlerFunc {
+		return func(ctx Context) {
+			h(ctx)
+			buf, ok := ctx.ResponseBuffer()
+			if !ok {
+				t.Fatal("Context.ResponseBuffer() not found")
+			}
+			if !buf.Buffered() {
+				return
+			}
+			body := strings.ToUpper(string(buf.Bytes()))
+			buf.Reset()
+			buf.SetStatus(StatusAccepted)
+			buf.Header().Set("X-Rewritten", "true")
+			buf.WriteString(body)
+		}
+	}
+
+	server := New()
+	server.Get("/small", BufferResponse(16)(rewrite(func(ctx Context) {
+		ctx.WriteHeader("X-Handler", "true")
+		ctx.WriteStatus(StatusCreated)
+		ctx.WriteString("zen")
+	})))
+	server.Get("/large", BufferResponse(4)(rewrite(func(ctx Context) {
+		ctx.WriteStatus(StatusCreated)
+		ctx.WriteString("zen")
+		ctx.WriteString("zen")
+	})))
+	server.Get("/flush", BufferResponse(16)(rewrite(func(ctx Context) {
+		ctx.WriteString("zen")
+		ctx.Response().Flush()
+		ctx.WriteString("zen")
+	})))
+
+	tests := []struct {
+		path      string
+		code      int
+		body      string
+		rewritten bool
+	}{
+		{"/small", StatusAccepted, "ZEN", true},
+		{"/large", StatusCreated, "zenzen", false},
+		{"/flush", StatusOK, "zenzen", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			server.ServeHTTP(rw, httptest.NewRequest("GET", tt.path, nil))
+			if rw.Code != tt.code || rw.Body.String() != tt.body {
+				t.Errorf("BufferResponse() got %d %s, want %d %s", rw.Code, rw.Body.String(), tt.code, tt.body)
+			}
+			if got := rw.Header().Get("X-Rewritten") == "true"; got != tt.rewritten {
+				t.Errorf("BufferResponse() rewritten = %v, want %v", got, tt.rewritten)
+			}
+		})
+	}
+}
+
+func TestBufferResponse_contentLength(t *testing.T) {
+	appendBody := func(h HandlerFunc) HandlerFunc {
+		return func(ctx Context) {
+			h(ctx)
+			ctx.Rw.Write([]byte("!!"))
+		}
+	}
+
+	server := New()
+	server.Get("/length", BufferResponse(16)(appendBody(func(ctx Context) {
+		ctx.WriteHeader(HeaderContentLength, "3")
+		ctx.WriteString("zen")
+	})))
+
+	rw := httptest.NewRecorder()
+	server.ServeHTTP(rw, httptest.NewRequest("GET", "/length", nil))
+	if cl := rw.Header().Get(HeaderContentLength); cl != "5" || rw.Body.String() != "zen!!" {
+		t.Errorf("BufferResponse() got Content-Length %s and body %q, want 5 and %q", cl, rw.Body.String(), "zen!!")
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"encoding/xml"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -25,18 +24,27 @@ const (
 
 // Headers
 const (
+	HeaderAccept                        = "Accept"
 	HeaderAcceptEncoding                = "Accept-Encoding"
 	HeaderAllow                         = "Allow"
 	HeaderAuthorization                 = "Authorization"
+	HeaderCacheControl                  = "Cache-Control"
 	HeaderContentDisposition            = "Content-Disposition"
 	HeaderContentEncoding               = "Content-Encoding"
 	HeaderContentLength                 = "Content-Length"
 	HeaderContentType                   = "Content-Type"
 	HeaderCookie                        = "Cookie"
+	HeaderETag                          = "ETag"
+	HeaderExpect                        = "Expect"
 	HeaderSetCookie                     = "Set-Cookie"
+	HeaderIfMatch                       = "If-Match"
 	HeaderIfModifiedSince               = "If-Modified-Since"
+	HeaderIfNoneMatch                   = "If-None-Match"
+	HeaderIfUnmodifiedSince             = "If-Unmodified-Since"
+	HeaderLastEventID                   = "Last-Event-ID"
 	HeaderLastModified                  = "Last-Modified"
 	HeaderLocation                      = "Location"
+	HeaderRange                         = "Range"
 	HeaderUpgrade                       = "Upgrade"
 	HeaderVary                          = "Vary"
 	HeaderWWWAuthenticate               = "WWW-Authenticate"
@@ -71,6 +79,7 @@ type (
 		Rw     http.ResponseWriter
 		params Params
 		parsed bool
+		server *Server
 		context.Context
 	}
 )
@@ -78,15 +87,26 @@ type (
 func getContext(rw http.ResponseWriter, req *http.Request) Context {
 	c := Context{}
 	c.Req = req
+	if rw != nil {
+		rw = newResponseWriter(rw)
+	}
 	c.Rw = rw
 	c.Context = context.TODO()
+	if req != nil {
+		// done when the client disconnects
+		c.Context = req.Context()
+	}
 	c.SetValue(fieldKey{}, fields{})
+	c.SetValue(requestFieldsKey{}, &requestFields{fields: fields{}})
 	return c
 }
 
-// parseInput will parse request's form and
+// parseInput will parse request's form and multipart form
 func (ctx *Context) parseInput() error {
 	ctx.parsed = true
+	if isMultipart(ctx.Req) {
+		return ctx.Req.ParseMultipartForm(defaultMultipartMemory)
+	}
 	return ctx.Req.ParseForm()
 }
 
@@ -98,6 +118,7 @@ func (ctx *Context) Dup(c context.Context) Context {
 	ret.Context = c
 	ret.parsed = ctx.parsed
 	ret.params = ctx.params
+	ret.server = ctx.server
 	return ret
 }
 
@@ -156,18 +177,19 @@ func (ctx *Context) ParseValidateForm(input interface{}) error {
 	return ctx.parseValidateForm(input)
 }
 
-// BindJSON will parse request's json body and map into a interface{} value
+// BindJSON will parse request's json body and map into a interface{} value,
+// errors caused by request body are returned as *BindError
 func (ctx *Context) BindJSON(input interface{}) error {
-	if err := json.NewDecoder(ctx.Req.Body).Decode(input); err != nil {
-		return err
-	}
-	return nil
+	ctx.rewindBody()
+	return decodeJSON(ctx.Req.Body, input, ctx.strictJSON())
 }
 
-// BindXML will parse request's xml body and map into a interface{} value
+// BindXML will parse request's xml body and map into a interface{} value,
+// errors caused by request body are returned as *BindError
 func (ctx *Context) BindXML(input interface{}) error {
+	ctx.rewindBody()
 	if err := xml.NewDecoder(ctx.Req.Body).Decode(input); err != nil {
-		return err
+		return newBindError(err)
 	}
 	return nil
 }
@@ -178,25 +200,65 @@ func (ctx *Context) parseValidateForm(input interface{}) error {
 
 	for i := 0; i < inputValue.NumField(); i++ {
 		tag := inputType.Field(i).Tag
+		field := inputValue.Field(i)
+
+		// bind uploaded files
+		if fileName := tag.Get(fileTagName); fileName != "" {
+			if err := ctx.bindFile(field, fileName); err != nil {
+				return err
+			}
+			continue
+		}
+
 		formName := tag.Get(inputTagName)
 		validate := tag.Get(validTagName)
 		validateMsg := tag.Get(validMsgName)
-		field := inputValue.Field(i)
 		formValue := ctx.Req.Form.Get(formName)
 
 		// scan form string value into field
 		if err := scan(field, formValue); err != nil {
-			return err
+			return &BindError{Kind: BindType, Field: formName, Err: err}
 		}
 		// validate form with regex
 		if err := valid(formValue, validate, validateMsg); err != nil {
-			return err
+			return withField(err, formName)
+		}
+
+	}
+	return nil
+}
+
+// validateStruct validate exported fields of a struct with their valid tag
+func validateStruct(input interface{}) error {
+	inputValue := reflect.Indirect(reflect.ValueOf(input))
+	if inputValue.Kind() != reflect.Struct {
+		return nil
+	}
+	inputType := inputValue.Type()
+
+	for i := 0; i < inputValue.NumField(); i++ {
+		tag := inputType.Field(i).Tag
+		validate := tag.Get(validTagName)
+		field := inputValue.Field(i)
+		if validate == "" || !field.CanInterface() {
+			continue
 		}
 
+		if err := valid(fmt.Sprint(field.Interface()), validate, tag.Get(validMsgName)); err != nil {
+			return withField(err, inputType.Field(i).Name)
+		}
 	}
 	return nil
 }
 
+// withField set the field name of a ValidationError
+func withField(err error, field string) error {
+	if verr, ok := err.(*ValidationError); ok {
+		verr.Field = field
+	}
+	return err
+}
+
 func scan(v reflect.Value, s string) error {
 
 	if !v.CanSet() {
@@ -249,7 +311,7 @@ func valid(s string, validate, msg string) error {
 	}
 
 	if !rxp.MatchString(s) {
-		return errors.New(msg)
+		return &ValidationError{Message: msg}
 	}
 
 	return nil
@@ -315,6 +377,22 @@ func (ctx *Context) SetField(key string, val interface{}) {
 	n := fields{key: val}
 	n.Merge(f)
 	ctx.SetValue(fieldKey{}, n)
+	if r, ok := ctx.Value(requestFieldsKey{}).(*requestFields); ok {
+		r.Set(key, val)
+	}
+}
+
+// withRequestFields add the fields set during the request by other contexts,
+// like the ones of interceptors and handler, to ctx fields
+func (ctx *Context) withRequestFields() {
+	r, ok := ctx.Value(requestFieldsKey{}).(*requestFields)
+	if !ok {
+		return
+	}
+	f := fields{}
+	f.Merge(ctx.fields())
+	r.MergeInto(f)
+	ctx.SetValue(fieldKey{}, f)
 }
 
 // LogError print error level log with fields
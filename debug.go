@@ -0,0 +1,164 @@
+package zen
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DebugEnv is the environment variable which must be "1" or "true" to enable debug mode
+const DebugEnv = "ZEN_DEBUG"
+
+// debugKey is the context value key of debugInfo
+type debugKey struct{}
+
+// debugInfo collect details of a request for the debug page
+type debugInfo struct {
+	route  string
+	params Params
+}
+
+// debugAllowed report whether debug mode is allowed by DebugEnv
+func debugAllowed() bool {
+	allowed, _ := strconv.ParseBool(os.Getenv(DebugEnv))
+	return allowed
+}
+
+// Debug report whether server is in debug mode
+func (s *Server) Debug() bool {
+	return s.debug
+}
+
+// warnings return suspicious configuration of server
+func (s *Server) warnings() []string {
+	var warnings []string
+	if s.ReadTimeout == 0 && s.ReadHeaderTimeout == 0 {
+		warnings = append(warnings, "ReadTimeout and ReadHeaderTimeout are zero, slow clients can hold connections forever")
+	}
+	if s.WriteTimeout == 0 {
+		warnings = append(warnings, "WriteTimeout is zero, slow clients can hold connections forever")
+	}
+	if s.MaxBodyBytes == 0 {
+		warnings = append(warnings, "MaxBodyBytes is zero, request body size is not limited")
+	}
+	if s.RedirectFixedPath {
+		for _, route := range s.routes {
+			if strings.Contains(route.Path, "/*") {
+				warnings = append(warnings, fmt.Sprintf("RedirectFixedPath is enabled with catch-all route %s %s, requests differing in case are redirected into it", route.Method, route.Path))
+			}
+		}
+	}
+	return warnings
+}
+
+// printDiagnostics log route table and configuration warnings
+func (s *Server) printDiagnostics(addr string) {
+	log.Warnf("zen: debug mode is enabled, do not use it in production")
+	log.Infof("zen: listening on %s with %d routes", addr, len(s.routes))
+	for _, route := range s.routes {
+		if route.In != nil {
+			log.Infof("zen: %-7s %s (in %v, out %v)", route.Method, route.Path, route.In, route.Out)
+			continue
+		}
+		log.Infof("zen: %-7s %s", route.Method, route.Path)
+	}
+	for _, warning := range s.warnings() {
+		log.Warn("zen: " + warning)
+	}
+}
+
+// traceRoute return a handler which record route pattern and params for the debug page
+func traceRoute(path string, handler HandlerFunc) HandlerFunc {
+	return func(ctx Context) {
+		if info, ok := ctx.Value(debugKey{}).(*debugInfo); ok {
+			info.route = path
+			info.params = ctx.params
+		}
+		handler(ctx)
+	}
+}
+
+var debugTemplate = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html>
+<head><title>{{.Status}} {{.StatusText}}</title>
+<style>body{font-family:sans-serif;margin:2em}pre{background:#f4f4f4;padding:1em;overflow:auto}td{padding:0 1em 0 0;vertical-align:top}</style>
+</head>
+<body>
+<h1>{{.Status}} {{.StatusText}}</h1>
+{{if .Error}}<pre>{{.Error}}</pre>{{end}}
+{{if .Stack}}<h2>Stack</h2><pre>{{.Stack}}</pre>{{end}}
+<h2>Request</h2>
+<table>
+<tr><td>Method</td><td>{{.Method}}</td></tr>
+<tr><td>URL</td><td>{{.URL}}</td></tr>
+<tr><td>Route</td><td>{{.Route}}</td></tr>
+</table>
+{{if .Params}}<h2>Params</h2><table>{{range .Params}}<tr><td>{{.Key}}</td><td>{{.Value}}</td></tr>{{end}}</table>{{end}}
+{{if .Fields}}<h2>Fields</h2><table>{{range $k, $v := .Fields}}<tr><td>{{$k}}</td><td>{{$v}}</td></tr>{{end}}</table>{{end}}
+<h2>Headers</h2>
+<table>{{range $k, $v := .Header}}<tr><td>{{$k}}</td><td>{{range $v}}{{.}}<br>{{end}}</td></tr>{{end}}</table>
+</body>
+</html>
+`))
+
+// credentialHeaders are masked on the debug page
+var credentialHeaders = []string{HeaderAuthorization, "Proxy-Authorization", HeaderCookie}
+
+// maskCredentials return a copy of header with values of credential headers masked
+func maskCredentials(header http.Header) http.Header {
+	header = header.Clone()
+	for _, key := range credentialHeaders {
+		for i := range header[key] {
+			header[key][i] = "******"
+		}
+	}
+	return header
+}
+
+// renderDebugPage render a html page with err, stack trace of recovered panic,
+// matched route, params, request headers and context fields
+func (ctx *Context) renderDebugPage(code int, err error) {
+	data := struct {
+		Status     int
+		StatusText string
+		Error      string
+		Stack      string
+		Method     string
+		URL        string
+		Route      string
+		Params     Params
+		Fields     fields
+		Header     http.Header
+	}{
+		Status:     code,
+		StatusText: StatusText(code),
+		Method:     ctx.Req.Method,
+		URL:        ctx.Req.URL.String(),
+		Params:     ctx.params,
+		Fields:     ctx.fields(),
+		Header:     maskCredentials(ctx.Req.Header),
+	}
+	if err != nil {
+		data.Error = err.Error()
+		var panicErr *PanicError
+		if errors.As(err, &panicErr) {
+			data.Stack = string(panicErr.Stack)
+		}
+	}
+	if info, ok := ctx.Value(debugKey{}).(*debugInfo); ok {
+		data.Route = info.route
+		data.Params = info.params
+	}
+
+	ctx.WriteHeader(HeaderContentType, MIMETextHTMLCharsetUTF8)
+	ctx.WriteStatus(code)
+	if err := debugTemplate.Execute(ctx.Rw, data); err != nil {
+		ctx.LogError(err)
+	}
+}
@@ -0,0 +1,112 @@
+package zen
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSetDebug(t *testing.T) {
+	SetLogOutput(&bytes.Buffer{})
+	defer SetLogOutput(os.Stderr)
+
+	tests := []struct {
+		name string
+		env  string
+		set  bool
+		want bool
+	}{
+		{"enabled", "1", true, true},
+		{"without env", "", true, false},
+		{"invalid env", "yes", true, false},
+		{"without option", "true", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(DebugEnv, tt.env)
+			if got := New(SetDebug(tt.set)).Debug(); got != tt.want {
+				t.Errorf("Server.Debug() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_warnings(t *testing.T) {
+	server := New(SetTimeout(0))
+	server.Static("/assets", ".")
+
+	warnings := strings.Join(server.warnings(), "\n")
+	for _, want := range []string{"ReadTimeout", "WriteTimeout", "MaxBodyBytes", "catch-all route GET /assets/*filepath"} {
+		if !strings.Contains(warnings, want) {
+			t.Errorf("Server.warnings() = %q, want contains %q", warnings, want)
+		}
+	}
+}
+
+func TestServer_renderDebugPage(t *testing.T) {
+	SetLogOutput(&bytes.Buffer{})
+	defer SetLogOutput(os.Stderr)
+	t.Setenv(DebugEnv, "true")
+
+	server := New(SetDebug(true))
+	server.Get("/panic/:id", func(ctx Context) {
+		panic("<boom>")
+	})
+	setField := func(h HandlerFunc) HandlerFunc {
+		return func(ctx Context) {
+			ctx.SetField("uid", "42")
+			h(ctx)
+		}
+	}
+	server.Get("/error", setField(WrapE(func(ctx Context) error {
+		return errors.New("database is down")
+	})))
+	server.Get("/status", setField(func(ctx Context) {
+		ctx.WriteStatus(StatusBadGateway)
+	}))
+	server.Get("/client", WrapE(func(ctx Context) error {
+		return NewHTTPError(StatusBadRequest, "")
+	}))
+
+	tests := []struct {
+		path     string
+		code     int
+		contains []string
+	}{
+		{"/panic/7", StatusInternalServerError, []string{"zen: panic: &lt;boom&gt;", "goroutine", "/panic/:id", "<td>id</td><td>7</td>"}},
+		{"/error", StatusInternalServerError, []string{"database is down", "<td>uid</td><td>42</td>", "<td>/error</td>"}},
+		{"/status", StatusBadGateway, []string{"502 Bad Gateway", "<td>uid</td><td>42</td>"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req.Header.Set(HeaderAuthorization, "Bearer secret-token")
+			req.Header.Set(HeaderCookie, "session=secret-session")
+			rw := httptest.NewRecorder()
+			server.ServeHTTP(rw, req)
+			if rw.Code != tt.code {
+				t.Errorf("Context.renderDebugPage() code = %d, want %d", rw.Code, tt.code)
+			}
+			if ct := rw.Header().Get(HeaderContentType); ct != MIMETextHTMLCharsetUTF8 {
+				t.Errorf("Context.renderDebugPage() content type = %q, want %q", ct, MIMETextHTMLCharsetUTF8)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(rw.Body.String(), want) {
+					t.Errorf("Context.renderDebugPage() body = %q, want contains %q", rw.Body.String(), want)
+				}
+			}
+			if strings.Contains(rw.Body.String(), "secret") {
+				t.Errorf("Context.renderDebugPage() body = %q, want credentials masked", rw.Body.String())
+			}
+		})
+	}
+
+	rw := httptest.NewRecorder()
+	server.ServeHTTP(rw, httptest.NewRequest("GET", "/client", nil))
+	if ct := rw.Header().Get(HeaderContentType); ct != MIMEApplicationProblemJSON {
+		t.Errorf("Context.Error() content type = %q, want %q for 4xx in debug mode", ct, MIMEApplicationProblemJSON)
+	}
+}
@@ -0,0 +1,187 @@
+package zen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+type (
+	// HandlerFuncE is a handler which returns error, the error is rendered by
+	// Server's error handler. Use WrapE to register it as a HandlerFunc.
+	HandlerFuncE func(Context) error
+
+	// ErrorHandler render err into the response
+	ErrorHandler func(ctx Context, err error)
+
+	// StatusCoder is implemented by errors which know the http status code to answer
+	StatusCoder interface {
+		StatusCode() int
+	}
+)
+
+// HTTPError is an error with http status code and a message which is safe to
+// send to the client, the internal cause is only logged
+type HTTPError struct {
+	Code int
+	// Message is the public message of the error
+	Message string
+	// Err is the internal cause of the error
+	Err error
+}
+
+// NewHTTPError create a HTTPError with status code and public message,
+// StatusText(code) is used if message is empty
+func NewHTTPError(code int, message string) *HTTPError {
+	if message == "" {
+		message = StatusText(code)
+	}
+	return &HTTPError{Code: code, Message: message}
+}
+
+// WithCause set the internal cause of e and return e
+func (e *HTTPError) WithCause(err error) *HTTPError {
+	e.Err = err
+	return e
+}
+
+func (e *HTTPError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%d %s: %v", e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%d %s", e.Code, e.Message)
+}
+
+// Unwrap return the internal cause
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode return the http status code of e
+func (e *HTTPError) StatusCode() int {
+	return e.Code
+}
+
+// ValidationError is returned when an input does not match its valid tag
+type ValidationError struct {
+	// Field is the form or struct field name which failed validation
+	Field string
+	// Message is taken from the msg tag, it is translated by Context.Error
+	// if the msg tag is a key known by the Translator of request
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// StatusCode return 422 Unprocessable Entity
+func (e *ValidationError) StatusCode() int {
+	return StatusUnprocessableEntity
+}
+
+// Problem is a RFC 7807 problem details object
+type Problem struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// WrapE wrap a HandlerFuncE into HandlerFunc, errors returned by h are passed to Context.Error
+func WrapE(h HandlerFuncE) HandlerFunc {
+	return func(ctx Context) {
+		if err := h(ctx); err != nil {
+			ctx.Error(err)
+		}
+	}
+}
+
+// HandleError set server's error handler, which render errors passed to Context.Error
+func (s *Server) HandleError(handler ErrorHandler) {
+	s.errorHandler = handler
+}
+
+// Error render err with server's error handler, ProblemErrorHandler is used by default.
+// If the response header is already written, err is only logged.
+func (ctx *Context) Error(err error) {
+	if err == nil {
+		return
+	}
+	if ctx.Response().Written() {
+		ctx.LogError(err)
+		return
+	}
+	err = ctx.localizeError(err)
+
+	if ctx.server != nil && ctx.server.debug && ErrorStatus(err) >= StatusInternalServerError {
+		ctx.renderDebugPage(ErrorStatus(err), err)
+		return
+	}
+	if ctx.server != nil && ctx.server.errorHandler != nil {
+		ctx.server.errorHandler(*ctx, err)
+		return
+	}
+	ProblemErrorHandler(*ctx, err)
+}
+
+// ErrorStatus return the http status code which should be answered for err,
+// errors implementing StatusCoder use their own code, unknown errors are 500
+func ErrorStatus(err error) int {
+	var (
+		coder    StatusCoder
+		tooLarge *http.MaxBytesError
+	)
+
+	switch {
+	case errors.As(err, &coder):
+		return coder.StatusCode()
+	case errors.As(err, &tooLarge):
+		return StatusRequestEntityTooLarge
+	case err == http.ErrNotMultipart:
+		return StatusUnsupportedMediaType
+	case err == http.ErrMissingFile:
+		return StatusBadRequest
+	}
+	return StatusInternalServerError
+}
+
+// ErrorMessage return the message of err which is safe to send to the client,
+// internal errors answered with 5xx have no public message unless they are HTTPError
+func ErrorMessage(err error) string {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Message
+	}
+	if ErrorStatus(err) >= StatusInternalServerError {
+		return ""
+	}
+	return err.Error()
+}
+
+// ProblemErrorHandler render err as RFC 7807 application/problem+json,
+// errors answered with 5xx are logged with context fields, except recovered
+// panics which are already logged with stack
+func ProblemErrorHandler(ctx Context, err error) {
+	code := ErrorStatus(err)
+	var panicErr *PanicError
+	if code >= StatusInternalServerError && !errors.As(err, &panicErr) {
+		ctx.LogError(err)
+	}
+
+	problem := Problem{
+		Type:   "about:blank",
+		Title:  StatusText(code),
+		Status: code,
+		Detail: ErrorMessage(err),
+	}
+	if ctx.Req != nil {
+		problem.Instance = ctx.Req.URL.Path
+	}
+
+	ctx.Rw.Header().Set(HeaderContentType, MIMEApplicationProblemJSON)
+	ctx.WriteStatus(code)
+	json.NewEncoder(ctx.Rw).Encode(problem)
+}
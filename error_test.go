@@ -0,0 +1,138 @@
+package zen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWrapE(t *testing.T) {
+	server := New()
+	server.Get("/http", WrapE(func(ctx Context) error {
+		return NewHTTPError(StatusForbidden, "no access").WithCause(errors.New("internal reason"))
+	}))
+	server.Get("/internal", WrapE(func(ctx Context) error {
+		return errors.New("database password is wrong")
+	}))
+	server.Get("/valid", WrapE(func(ctx Context) error {
+		var input struct {
+			Name string `form:"name" valid:"^[a-z]+$" msg:"illegal name"`
+		}
+		return ctx.ParseValidateForm(&input)
+	}))
+	server.Post("/bind", WrapE(func(ctx Context) error {
+		var input map[string]string
+		return ctx.BindJSON(&input)
+	}))
+	server.Get("/written", WrapE(func(ctx Context) error {
+		ctx.WriteStatus(StatusAccepted)
+		return errors.New("too late")
+	}))
+	server.Get("/ok", WrapE(func(ctx Context) error {
+		return ctx.JSON("zen")
+	}))
+
+	tests := []struct {
+		method, path string
+		code         int
+		detail       string
+	}{
+		{"GET", "/http", StatusForbidden, "no access"},
+		{"GET", "/internal", StatusInternalServerError, ""},
+		{"GET", "/valid?name=ZEN", StatusUnprocessableEntity, "illegal name"},
+		{"POST", "/bind", StatusBadRequest, "zen: bind syntax error: unexpected EOF"},
+		{"GET", "/written", StatusAccepted, ""},
+		{"GET", "/ok", StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			server.ServeHTTP(rw, httptest.NewRequest(tt.method, tt.path, strings.NewReader("{")))
+			if rw.Code != tt.code {
+				t.Errorf("WrapE() code = %d, want %d", rw.Code, tt.code)
+			}
+			if rw.Code < 300 {
+				return
+			}
+
+			if ct := rw.Header().Get(HeaderContentType); ct != MIMEApplicationProblemJSON {
+				t.Errorf("WrapE() content type = %s, want %s", ct, MIMEApplicationProblemJSON)
+			}
+			var problem Problem
+			json.NewDecoder(rw.Body).Decode(&problem)
+			if problem.Status != tt.code || problem.Detail != tt.detail || problem.Instance != tt.path[:strings.IndexAny(tt.path+"?", "?")] {
+				t.Errorf("WrapE() problem = %+v", problem)
+			}
+		})
+	}
+}
+
+type mapTranslator map[string]string
+
+func (m mapTranslator) Translate(key string, args ...interface{}) (string, bool) {
+	message, ok := m[key]
+	return message, ok
+}
+
+func TestContext_localizeError(t *testing.T) {
+	verr := &ValidationError{Field: "name", Message: "name_required"}
+	wrapped := fmt.Errorf("create user: %w", verr)
+	tests := []struct {
+		name    string
+		err     error
+		message string
+		check   func(error) bool
+	}{
+		{"validation", verr, "nom requis", func(err error) bool {
+			_, ok := err.(*ValidationError)
+			return ok
+		}},
+		{"patch", &PatchError{Code: StatusUnprocessableEntity, Err: verr}, "nom requis", func(err error) bool {
+			e, ok := err.(*PatchError)
+			return ok && e.Code == StatusUnprocessableEntity
+		}},
+		{"http", NewHTTPError(StatusBadRequest, "invalid").WithCause(verr), "nom requis", func(err error) bool {
+			e, ok := err.(*HTTPError)
+			return ok && e.Message == "invalid"
+		}},
+		{"other wrapper", wrapped, "name_required", func(err error) bool {
+			return err == wrapped
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := getContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+			ctx.SetTranslator(mapTranslator{"name_required": "nom requis"})
+			err := ctx.localizeError(tt.err)
+
+			var got *ValidationError
+			if !tt.check(err) || !errors.As(err, &got) || got.Message != tt.message {
+				t.Errorf("Context.localizeError() = %#v, want message %q", err, tt.message)
+			}
+		})
+	}
+	if verr.Message != "name_required" {
+		t.Errorf("Context.localizeError() changed the original error to %q", verr.Message)
+	}
+}
+
+func TestServer_HandleError(t *testing.T) {
+	server := New()
+	var handled error
+	server.HandleError(func(ctx Context, err error) {
+		handled = err
+		ctx.WriteStatus(ErrorStatus(err))
+	})
+	server.Get("/error", WrapE(func(ctx Context) error {
+		return NewHTTPError(StatusTeapot, "")
+	}))
+
+	rw := httptest.NewRecorder()
+	server.ServeHTTP(rw, httptest.NewRequest("GET", "/error", nil))
+	if handled == nil || rw.Code != StatusTeapot {
+		t.Errorf("Server.HandleError() handled %v with code %d", handled, rw.Code)
+	}
+}
@@ -0,0 +1,203 @@
+package zen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultETagBufferLimit is the default max size of response body ETag middleware hash,
+// larger responses are streamed without ETag
+const DefaultETagBufferLimit = 1 << 20
+
+// ETagOption customize ETag middleware
+type ETagOption func(*etagConfig)
+
+type etagConfig struct {
+	bufferLimit int
+	lookup      func(ctx Context) (etag string, exists bool)
+}
+
+// ETagBufferLimit return ETagOption for set the max size of response body hashed
+// by ETag middleware, default is DefaultETagBufferLimit
+func ETagBufferLimit(n int) ETagOption {
+	return func(c *etagConfig) {
+		c.bufferLimit = n
+	}
+}
+
+// ETagLookup return ETagOption for set the func returning the ETag of the current
+// representation of the requested resource, e.g. from a version column, exists is
+// false if there is none. It is called for requests of other methods than GET and HEAD
+// with If-Match or If-None-Match, so preconditions are checked before calling handler.
+func ETagLookup(fn func(ctx Context) (etag string, exists bool)) ETagOption {
+	return func(c *etagConfig) {
+		c.lookup = fn
+	}
+}
+
+// SetETag set ETag header of the response, tag is quoted and prefixed with W/ if weak
+func (ctx *Context) SetETag(tag string, weak bool) {
+	ctx.Rw.Header().Set(HeaderETag, formatETag(tag, weak))
+}
+
+// CheckPreconditions evaluate If-Match and If-None-Match of the request against
+// the ETag header of the response, set it with SetETag first. It return false
+// if the request must not proceed, after answering 304 Not Modified for GET and
+// HEAD requests, or 412 Precondition Failed with Context.Error.
+func (ctx *Context) CheckPreconditions() bool {
+	code := evaluatePreconditions(ctx.Req, ctx.Rw.Header().Get(HeaderETag), true)
+	switch code {
+	case 0:
+		return true
+	case StatusNotModified:
+		ctx.WriteStatus(code)
+	default:
+		ctx.Error(NewHTTPError(code, ""))
+	}
+	return false
+}
+
+// ETag return a Middleware which make conditional requests of a route work
+// without changes of handler.
+// Responses of GET and HEAD requests are buffered to compute a strong, or weak
+// if weak is true, ETag of the body unless handler set one with SetETag, then
+// 304 Not Modified is answered if If-None-Match matches, and 412 Precondition Failed
+// if If-Match does not match. Bodies larger than the buffer limit are streamed without ETag.
+// For other methods with If-Match or If-None-Match, 412 Precondition Failed is answered
+// without calling handler if the ETag returned by the ETagLookup func does not satisfy
+// the preconditions, to support optimistic concurrency of PUT and PATCH. Without
+// ETagLookup, handler check them with SetETag and CheckPreconditions.
+func ETag(weak bool, options ...ETagOption) Middleware {
+	config := etagConfig{bufferLimit: DefaultETagBufferLimit}
+	for _, option := range options {
+		option(&config)
+	}
+
+	return func(h HandlerFunc) HandlerFunc {
+		return func(ctx Context) {
+			if ctx.Req.Method != GET && ctx.Req.Method != HEAD {
+				if config.lookup != nil && hasPreconditions(ctx.Req) {
+					etag, exists := config.lookup(ctx)
+					if code := evaluatePreconditions(ctx.Req, etag, exists); code != 0 {
+						ctx.Error(NewHTTPError(code, ""))
+						return
+					}
+				}
+				h(ctx)
+				return
+			}
+
+			buf := newResponseBuffer(ctx.Response(), config.bufferLimit)
+			ctx.Rw = buf
+			hctx := ctx
+			if ctx.Req.Method == HEAD {
+				// handlers like ServeContent write no body for HEAD, serve it as GET
+				// so the ETag is the one of GET, then drop the body.
+				// A streamed body is discarded by net/http
+				hctx.Req = ctx.Req.Clone(ctx.Req.Context())
+				hctx.Req.Method = GET
+			}
+			h(hctx)
+			// not committed if h panics, the response is answered by the recovery
+			defer buf.Commit()
+			if ctx.Req.Method == HEAD {
+				defer dropBody(buf)
+			}
+
+			status := buf.Status()
+			if !buf.Buffered() || (status != 0 && status != StatusOK) {
+				return
+			}
+			etag := buf.Header().Get(HeaderETag)
+			if etag == "" {
+				sum := sha256.Sum256(buf.Bytes())
+				etag = formatETag(hex.EncodeToString(sum[:16]), weak)
+				buf.Header().Set(HeaderETag, etag)
+			}
+
+			switch evaluatePreconditions(ctx.Req, etag, true) {
+			case StatusNotModified:
+				buf.Reset()
+				buf.Header().Del(HeaderContentType)
+				buf.Header().Del(HeaderContentLength)
+				buf.SetStatus(StatusNotModified)
+			case StatusPreconditionFailed:
+				buf.Reset()
+				buf.SetStatus(0)
+				buf.Header().Del(HeaderETag)
+				buf.Header().Del(HeaderContentLength)
+				ctx.Error(NewHTTPError(StatusPreconditionFailed, ""))
+			}
+		}
+	}
+}
+
+// dropBody discard the body of a buffered response to a HEAD request,
+// the Content-Length of the body is kept
+func dropBody(buf *responseBuffer) {
+	if !buf.Buffered() {
+		return
+	}
+	if buf.Header().Get(HeaderContentLength) == "" && bodyAllowed(buf.Status()) {
+		buf.Header().Set(HeaderContentLength, strconv.Itoa(len(buf.Bytes())))
+	}
+	buf.Reset()
+}
+
+// formatETag quote tag as an entity tag
+func formatETag(tag string, weak bool) string {
+	tag = `"` + tag + `"`
+	if weak {
+		tag = "W/" + tag
+	}
+	return tag
+}
+
+// hasPreconditions report whether req has If-Match or If-None-Match header
+func hasPreconditions(req *http.Request) bool {
+	return req.Header.Get(HeaderIfMatch) != "" || req.Header.Get(HeaderIfNoneMatch) != ""
+}
+
+// evaluatePreconditions return the status code to answer for If-Match and If-None-Match
+// of req against etag of the current representation, which exists or not,
+// 0 is returned if the request should proceed
+func evaluatePreconditions(req *http.Request, etag string, exists bool) int {
+	if header := req.Header.Get(HeaderIfMatch); header != "" {
+		if !exists || !matchETag(header, etag, true) {
+			return StatusPreconditionFailed
+		}
+	}
+	if header := req.Header.Get(HeaderIfNoneMatch); header != "" {
+		if exists && matchETag(header, etag, false) {
+			if req.Method == GET || req.Method == HEAD {
+				return StatusNotModified
+			}
+			return StatusPreconditionFailed
+		}
+	}
+	return 0
+}
+
+// matchETag report whether etag matches the list of entity tags in header,
+// with strong comparison if strong is true, or weak comparison
+func matchETag(header, etag string, strong bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	if etag == "" || (strong && strings.HasPrefix(etag, "W/")) {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strong && strings.HasPrefix(candidate, "W/") {
+			continue
+		}
+		if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,179 @@
+package zen
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestETag(t *testing.T) {
+	version := "1"
+	var updated bool
+
+	server := New()
+	server.AddInterceptor(ETag(false, ETagLookup(func(ctx Context) (string, bool) {
+		if ctx.Req.URL.Path != "/item" {
+			return "", false
+		}
+		return `"v` + version + `"`, true
+	})))
+	server.Get("/item", func(ctx Context) {
+		ctx.WriteString("item v" + version)
+	})
+	server.Get("/tagged", func(ctx Context) {
+		ctx.SetETag("v"+version, true)
+		ctx.WriteString("tagged")
+	})
+	server.Put("/item", func(ctx Context) {
+		updated = true
+		ctx.WriteStatus(StatusNoContent)
+	})
+	server.Put("/new", func(ctx Context) {
+		updated = true
+		ctx.WriteStatus(StatusCreated)
+	})
+
+	rw := httptest.NewRecorder()
+	server.ServeHTTP(rw, httptest.NewRequest("GET", "/item", nil))
+	etag := rw.Header().Get(HeaderETag)
+	if len(etag) < 3 || etag[0] != '"' {
+		t.Fatalf("ETag() etag = %q, want strong entity tag", etag)
+	}
+
+	tests := []struct {
+		name         string
+		method, path string
+		header       string
+		value        string
+		code         int
+		updated      bool
+	}{
+		{"get", "GET", "/item", "", "", StatusOK, false},
+		{"not modified", "GET", "/item", HeaderIfNoneMatch, `"x", ` + etag, StatusNotModified, false},
+		{"weak not modified", "GET", "/item", HeaderIfNoneMatch, "W/" + etag, StatusNotModified, false},
+		{"modified", "GET", "/item", HeaderIfNoneMatch, `"x"`, StatusOK, false},
+		{"get if-match failed", "GET", "/item", HeaderIfMatch, `"x"`, StatusPreconditionFailed, false},
+		{"handler etag", "GET", "/tagged", HeaderIfNoneMatch, `W/"v1"`, StatusNotModified, false},
+		{"put matched", "PUT", "/item", HeaderIfMatch, `"v1"`, StatusNoContent, true},
+		{"put stale", "PUT", "/item", HeaderIfMatch, `"stale"`, StatusPreconditionFailed, false},
+		{"put weak", "PUT", "/item", HeaderIfMatch, `W/"v1"`, StatusPreconditionFailed, false},
+		{"put any", "PUT", "/item", HeaderIfMatch, "*", StatusNoContent, true},
+		{"create existing", "PUT", "/item", HeaderIfNoneMatch, "*", StatusPreconditionFailed, false},
+		{"create new", "PUT", "/new", HeaderIfNoneMatch, "*", StatusCreated, true},
+		{"update missing", "PUT", "/new", HeaderIfMatch, "*", StatusPreconditionFailed, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated = false
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rw := httptest.NewRecorder()
+			server.ServeHTTP(rw, req)
+			if rw.Code != tt.code {
+				t.Errorf("ETag() code = %d, want %d", rw.Code, tt.code)
+			}
+			if updated != tt.updated {
+				t.Errorf("ETag() handler called = %v, want %v", updated, tt.updated)
+			}
+			if tt.code == StatusNotModified && rw.Body.Len() != 0 {
+				t.Errorf("ETag() body = %q, want empty", rw.Body.String())
+			}
+		})
+	}
+}
+
+func TestETagHead(t *testing.T) {
+	server := New()
+	server.AddInterceptor(ETag(false))
+	serve := func(ctx Context) {
+		ctx.ServeContent("doc.txt", time.Time{}, strings.NewReader("document"))
+	}
+	server.Get("/doc", serve)
+	server.Head("/doc", serve)
+
+	get := httptest.NewRecorder()
+	server.ServeHTTP(get, httptest.NewRequest("GET", "/doc", nil))
+	head := httptest.NewRecorder()
+	server.ServeHTTP(head, httptest.NewRequest("HEAD", "/doc", nil))
+
+	if etag := get.Header().Get(HeaderETag); etag == "" || head.Header().Get(HeaderETag) != etag {
+		t.Errorf("ETag() HEAD etag = %q, want %q", head.Header().Get(HeaderETag), etag)
+	}
+	if head.Header().Get(HeaderContentLength) != "8" {
+		t.Errorf("ETag() HEAD Content-Length = %q, want 8", head.Header().Get(HeaderContentLength))
+	}
+	if head.Body.Len() != 0 {
+		t.Errorf("ETag() HEAD body = %q, want empty", head.Body.String())
+	}
+
+	req := httptest.NewRequest("HEAD", "/doc", nil)
+	req.Header.Set(HeaderIfNoneMatch, get.Header().Get(HeaderETag))
+	rw := httptest.NewRecorder()
+	server.ServeHTTP(rw, req)
+	if rw.Code != StatusNotModified {
+		t.Errorf("ETag() HEAD code = %d, want %d", rw.Code, StatusNotModified)
+	}
+}
+
+func TestETagBufferLimit(t *testing.T) {
+	server := New()
+	server.AddInterceptor(ETag(false, ETagBufferLimit(4)))
+	server.Get("/small", func(ctx Context) {
+		ctx.WriteString("abc")
+	})
+	server.Get("/large", func(ctx Context) {
+		ctx.WriteString("abcdefgh")
+	})
+
+	tests := []struct {
+		path string
+		etag bool
+	}{
+		{"/small", true},
+		{"/large", false},
+	}
+	for _, tt := range tests {
+		rw := httptest.NewRecorder()
+		server.ServeHTTP(rw, httptest.NewRequest("GET", tt.path, nil))
+		if got := rw.Header().Get(HeaderETag) != ""; got != tt.etag || rw.Code != StatusOK {
+			t.Errorf("ETag(%s) code = %d, has etag = %v, want %v", tt.path, rw.Code, got, tt.etag)
+		}
+	}
+}
+
+func TestContext_CheckPreconditions(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		header string
+		value  string
+		want   bool
+		code   int
+	}{
+		{"no condition", "PUT", "", "", true, StatusOK},
+		{"if-match", "PUT", HeaderIfMatch, `"v1"`, true, StatusOK},
+		{"if-match failed", "PUT", HeaderIfMatch, `"v0"`, false, StatusPreconditionFailed},
+		{"if-none-match get", "GET", HeaderIfNoneMatch, `"v1"`, false, StatusNotModified},
+		{"if-none-match put", "PUT", HeaderIfNoneMatch, "*", false, StatusPreconditionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rw := httptest.NewRecorder()
+			ctx := getContext(rw, req)
+			ctx.SetETag("v1", false)
+			if got := ctx.CheckPreconditions(); got != tt.want {
+				t.Errorf("Context.CheckPreconditions() = %v, want %v", got, tt.want)
+			}
+			if rw.Code != tt.code {
+				t.Errorf("Context.CheckPreconditions() code = %d, want %d", rw.Code, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package zen
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ContinueHook inspect a routed request before its body is read,
+// it returns 0 to accept the request, or the status code to reject it with
+type ContinueHook func(ctx Context) int
+
+// expectContinue report whether the client waits for 100 Continue before sending body
+func expectContinue(req *http.Request) bool {
+	return strings.EqualFold(req.Header.Get(HeaderExpect), "100-continue")
+}
+
+// ExpectContinue return a Middleware which run hook before the request body is read.
+// The net/http server only sends 100 Continue to a client using "Expect: 100-continue"
+// when the handler first reads the body, so a rejected request is answered with
+// hook's status code without the client transmitting its payload.
+// Route params are available to hook, and it should be placed after middleware which
+// only inspect headers and before any middleware which read the body, e.g. CacheBody.
+func ExpectContinue(hook ContinueHook) Middleware {
+	return func(h HandlerFunc) HandlerFunc {
+		return func(ctx Context) {
+			if code := hook(ctx); code != 0 && code != StatusContinue {
+				if expectContinue(ctx.Req) {
+					// the unread body will not be sent, the connection can not be reused
+					ctx.WriteHeader("Connection", "close")
+				}
+				ctx.Error(NewHTTPError(code, ""))
+				return
+			}
+			h(ctx)
+		}
+	}
+}
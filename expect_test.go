@@ -0,0 +1,69 @@
+package zen
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExpectContinue(t *testing.T) {
+	server := New()
+	server.Post("/upload/:user", ExpectContinue(func(ctx Context) int {
+		if ctx.Param("user") != "zen" {
+			return StatusUnauthorized
+		}
+		return 0
+	})(func(ctx Context) {
+		body, _ := ioutil.ReadAll(ctx.Req.Body)
+		ctx.WriteString(string(body))
+	}))
+
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	tests := []struct {
+		name    string
+		user    string
+		proceed bool
+		code    int
+	}{
+		{"accepted", "zen", true, StatusOK},
+		{"rejected", "gopher", false, StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+
+			conn.Write([]byte("POST /upload/" + tt.user + " HTTP/1.1\r\nHost: zen\r\nContent-Length: 3\r\nExpect: 100-continue\r\n\r\n"))
+			reader := bufio.NewReader(conn)
+			resp, err := http.ReadResponse(reader, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := resp.StatusCode == StatusContinue; got != tt.proceed {
+				t.Fatalf("ExpectContinue() got status %d before body", resp.StatusCode)
+			}
+			if tt.proceed {
+				conn.Write([]byte("zen"))
+				if resp, err = http.ReadResponse(reader, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if resp.StatusCode != tt.code {
+				t.Errorf("ExpectContinue() code = %d, want %d", resp.StatusCode, tt.code)
+			}
+			if body, _ := ioutil.ReadAll(resp.Body); tt.proceed && !strings.Contains(string(body), "zen") {
+				t.Errorf("ExpectContinue() body = %s, want %s", body, "zen")
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package zen
 
+import "sync"
+
 type fieldKey struct{}
 
 type fields map[string]interface{}
@@ -11,3 +13,24 @@ func (f fields) Merge(m fields) {
 		}
 	}
 }
+
+type requestFieldsKey struct{}
+
+// requestFields collect the fields set anywhere during a request, so they are
+// logged by the server when the context which set them is gone
+type requestFields struct {
+	mu     sync.Mutex
+	fields fields
+}
+
+func (r *requestFields) Set(key string, val interface{}) {
+	r.mu.Lock()
+	r.fields[key] = val
+	r.mu.Unlock()
+}
+
+func (r *requestFields) MergeInto(f fields) {
+	r.mu.Lock()
+	f.Merge(r.fields)
+	r.mu.Unlock()
+}
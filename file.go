@@ -0,0 +1,161 @@
+package zen
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// DispositionAttachment ask the client to download the response
+	DispositionAttachment = "attachment"
+	// DispositionInline ask the client to display the response
+	DispositionInline = "inline"
+)
+
+// ServeContent serve content with name and modification time, Range, If-Range,
+// If-Modified-Since and If-None-Match are honored, set ETag header before calling
+// it for If-None-Match. Content-Type is detected from name's extension, or
+// from content if the extension is unknown and Content-Type is not set.
+func (ctx *Context) ServeContent(name string, modtime time.Time, content io.ReadSeeker) {
+	http.ServeContent(ctx.Rw, ctx.Req, name, modtime, content)
+}
+
+// Stream write r with contentType, if r is an io.ReadSeeker it is served with
+// ServeContent so ranges and conditional requests are honored, otherwise it is
+// copied as it is and ranges are not accepted
+func (ctx *Context) Stream(contentType string, r io.Reader) error {
+	if contentType != "" {
+		ctx.Rw.Header().Set(HeaderContentType, contentType)
+	}
+	if rs, ok := r.(io.ReadSeeker); ok {
+		ctx.ServeContent("", time.Time{}, rs)
+		return nil
+	}
+
+	ctx.Rw.Header().Set("Accept-Ranges", "none")
+	_, err := io.Copy(ctx.Rw, r)
+	return err
+}
+
+// FileFS serve file name of fsys with ServeContent
+func (ctx *Context) FileFS(fsys fs.FS, name string) {
+	ctx.serveFS(fsys, name, "", "")
+}
+
+// serveFS serve file name of fsys, with Content-Disposition if dispositionType is not empty
+func (ctx *Context) serveFS(fsys fs.FS, name, dispositionType, filename string) {
+	f, err := fsys.Open(strings.TrimPrefix(name, "/"))
+	if err != nil {
+		ctx.Error(fileError(err))
+		return
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		ctx.Error(fileError(err))
+		return
+	}
+	if fi.IsDir() {
+		ctx.Error(NewHTTPError(StatusNotFound, ""))
+		return
+	}
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		content = bytes.NewReader(data)
+	}
+	if dispositionType != "" {
+		if filename == "" {
+			filename = fi.Name()
+		}
+		ctx.SetContentDisposition(dispositionType, filename)
+	}
+	ctx.ServeContent(fi.Name(), fi.ModTime(), content)
+}
+
+// Attachment serve file on disk to be downloaded as name, the base name of file is used if name is empty
+func (ctx *Context) Attachment(file, name string) {
+	ctx.serveFS(os.DirFS(filepath.Dir(file)), filepath.Base(file), DispositionAttachment, name)
+}
+
+// Inline serve file on disk to be displayed as name, the base name of file is used if name is empty
+func (ctx *Context) Inline(file, name string) {
+	ctx.serveFS(os.DirFS(filepath.Dir(file)), filepath.Base(file), DispositionInline, name)
+}
+
+// SetContentDisposition set Content-Disposition header with filename encoded
+// as RFC 6266, non-ASCII filenames are sent in filename* with an ASCII fallback
+func (ctx *Context) SetContentDisposition(dispositionType, filename string) {
+	ctx.Rw.Header().Set(HeaderContentDisposition, contentDisposition(dispositionType, filename))
+}
+
+// contentDisposition format a Content-Disposition header value
+func contentDisposition(dispositionType, filename string) string {
+	if filename == "" {
+		return dispositionType
+	}
+
+	var (
+		fallback strings.Builder
+		ascii    = true
+	)
+	for _, r := range filename {
+		switch {
+		case r >= 0x80 || r < 0x20 || r == 0x7f:
+			ascii = false
+			fallback.WriteByte('_')
+		case r == '"' || r == '\\':
+			fallback.WriteByte('\\')
+			fallback.WriteRune(r)
+		default:
+			fallback.WriteRune(r)
+		}
+	}
+
+	value := dispositionType + `; filename="` + fallback.String() + `"`
+	if !ascii {
+		value += "; filename*=UTF-8''" + encodeRFC5987(filename)
+	}
+	return value
+}
+
+// encodeRFC5987 percent encode s except attr-char of RFC 5987
+func encodeRFC5987(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("!#$&+-.^_`|~", c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&0xf])
+	}
+	return b.String()
+}
+
+// fileError convert errors of opening file into HTTPError
+func fileError(err error) error {
+	switch {
+	case errors.Is(err, fs.ErrNotExist), errors.Is(err, fs.ErrInvalid):
+		return NewHTTPError(StatusNotFound, "").WithCause(err)
+	case errors.Is(err, fs.ErrPermission):
+		return NewHTTPError(StatusForbidden, "").WithCause(err)
+	}
+	return err
+}
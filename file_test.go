@@ -0,0 +1,163 @@
+package zen
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestContext_ServeContent(t *testing.T) {
+	modtime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	server := New()
+	server.Get("/export", func(ctx Context) {
+		ctx.WriteHeader("ETag", `"v1"`)
+		ctx.ServeContent("export.csv", modtime, strings.NewReader("id,name\n1,zen\n"))
+	})
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		code   int
+		body   string
+	}{
+		{"full", nil, StatusOK, "id,name\n1,zen\n"},
+		{"range", map[string]string{"Range": "bytes=3-6"}, StatusPartialContent, "name"},
+		{"if-none-match", map[string]string{"If-None-Match": `"v1"`}, StatusNotModified, ""},
+		{"if-modified-since", map[string]string{"If-Modified-Since": modtime.Format(http.TimeFormat)}, StatusNotModified, ""},
+		{"stale etag", map[string]string{"If-None-Match": `"v0"`}, StatusOK, "id,name\n1,zen\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/export", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			rw := httptest.NewRecorder()
+			server.ServeHTTP(rw, req)
+			if rw.Code != tt.code {
+				t.Errorf("Context.ServeContent() code = %d, want %d", rw.Code, tt.code)
+			}
+			if body := rw.Body.String(); body != tt.body {
+				t.Errorf("Context.ServeContent() body = %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestContext_Stream(t *testing.T) {
+	tests := []struct {
+		name   string
+		reader io.Reader
+		code   int
+		body   string
+		ranges string
+	}{
+		{"seeker", strings.NewReader("0123456789"), StatusPartialContent, "234", "bytes"},
+		{"reader", io.MultiReader(strings.NewReader("0123456789")), StatusOK, "0123456789", "none"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Range", "bytes=2-4")
+			rw := httptest.NewRecorder()
+			ctx := getContext(rw, req)
+			if err := ctx.Stream(MIMETextPlain, tt.reader); err != nil {
+				t.Fatalf("Context.Stream() error = %v", err)
+			}
+			if rw.Code != tt.code || rw.Body.String() != tt.body {
+				t.Errorf("Context.Stream() = %d %q, want %d %q", rw.Code, rw.Body.String(), tt.code, tt.body)
+			}
+			if ranges := rw.Header().Get("Accept-Ranges"); ranges != tt.ranges {
+				t.Errorf("Context.Stream() Accept-Ranges = %q, want %q", ranges, tt.ranges)
+			}
+			if ct := rw.Header().Get(HeaderContentType); ct != MIMETextPlain {
+				t.Errorf("Context.Stream() Content-Type = %q, want %q", ct, MIMETextPlain)
+			}
+		})
+	}
+}
+
+func TestContext_FileFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"docs/readme.txt": {Data: []byte("hello zen"), ModTime: time.Now()},
+	}
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/docs/readme.txt", StatusOK, "hello zen"},
+		{"docs/readme.txt", StatusOK, "hello zen"},
+		{"/docs", StatusNotFound, ""},
+		{"/missing.txt", StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			ctx := getContext(rw, httptest.NewRequest("GET", "/", nil))
+			ctx.FileFS(fsys, tt.path)
+			if rw.Code != tt.code {
+				t.Errorf("Context.FileFS() code = %d, want %d", rw.Code, tt.code)
+			}
+			if tt.body != "" && rw.Body.String() != tt.body {
+				t.Errorf("Context.FileFS() body = %q, want %q", rw.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+func TestContext_Attachment(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "report.csv")
+	if err := os.WriteFile(file, []byte("id\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		serve       func(ctx Context)
+		code        int
+		disposition string
+	}{
+		{"attachment", func(ctx Context) { ctx.Attachment(file, "") }, StatusOK, `attachment; filename="report.csv"`},
+		{"inline", func(ctx Context) { ctx.Inline(file, "today.csv") }, StatusOK, `inline; filename="today.csv"`},
+		{"missing", func(ctx Context) { ctx.Attachment(file+".bak", "report.csv") }, StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			tt.serve(getContext(rw, httptest.NewRequest("GET", "/", nil)))
+			if rw.Code != tt.code {
+				t.Errorf("Context.Attachment() code = %d, want %d", rw.Code, tt.code)
+			}
+			if disposition := rw.Header().Get(HeaderContentDisposition); disposition != tt.disposition {
+				t.Errorf("Context.Attachment() Content-Disposition = %q, want %q", disposition, tt.disposition)
+			}
+		})
+	}
+}
+
+func Test_contentDisposition(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     string
+	}{
+		{"", "attachment"},
+		{"report.csv", `attachment; filename="report.csv"`},
+		{`say "hi".txt`, `attachment; filename="say \"hi\".txt"`},
+		{"résumé 2020.pdf", `attachment; filename="r_sum_ 2020.pdf"; filename*=UTF-8''r%C3%A9sum%C3%A9%202020.pdf`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			if got := contentDisposition(DispositionAttachment, tt.filename); got != tt.want {
+				t.Errorf("contentDisposition() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,20 +1,32 @@
 package zen
 
-import "path"
+import (
+	"path"
+	"reflect"
+	"strings"
+)
 
 type group struct {
 	base         string
 	interceptors Middlewares
 	server       *Server
+	statusPages  []statusPage
+
+	notFoundHandler       HandlerFunc
+	methodNotAllowed      HandlerFunc
+	redirectTrailingSlash *bool
+	redirectFixedPath     *bool
 }
 
 // Group create a group router with base url and shared interceptors
 func (s *Server) Group(base string, interceptors ...Middleware) Router {
-	return &group{
+	g := &group{
 		base:         base,
 		interceptors: interceptors,
 		server:       s,
 	}
+	s.groups = append(s.groups, g)
+	return g
 }
 
 // route set handler for given pattern and method
@@ -88,6 +100,48 @@ func (g *group) AddInterceptor(interceptor Middleware) {
 	g.interceptors = append(g.interceptors, interceptor)
 }
 
+// HandleNotFound set handler for requests under group's base which match no route,
+// it is called with group's interceptors
+func (g *group) HandleNotFound(handler HandlerFunc) {
+	g.notFoundHandler = handler
+}
+
+// HandleNotAllowed set handler for requests under group's base which match a route
+// with another method, it is called with group's interceptors
+func (g *group) HandleNotAllowed(handler HandlerFunc) {
+	g.methodNotAllowed = handler
+}
+
+// SetRedirectTrailingSlash override server's RedirectTrailingSlash for requests under group's base
+func (g *group) SetRedirectTrailingSlash(b bool) {
+	g.redirectTrailingSlash = &b
+}
+
+// SetRedirectFixedPath override server's RedirectFixedPath for requests under group's base
+func (g *group) SetRedirectFixedPath(b bool) {
+	g.redirectFixedPath = &b
+}
+
+// matchBase report whether path is under group base
+func matchBase(base, path string) bool {
+	base = strings.TrimSuffix(base, "/")
+	return base == "" || path == base || strings.HasPrefix(path, base+"/")
+}
+
+// lookupGroup return the group with longest base matching path for which ok return true
+func (s *Server) lookupGroup(path string, ok func(g *group) bool) *group {
+	var found *group
+	for _, g := range s.groups {
+		if found != nil && len(g.base) <= len(found.base) {
+			continue
+		}
+		if matchBase(g.base, path) && ok(g) {
+			found = g
+		}
+	}
+	return found
+}
+
 func joinPath(base, sub string) string {
 	ret := path.Join(base, sub)
 	if sub[len(sub)-1] == '/' {
@@ -96,6 +150,11 @@ func joinPath(base, sub string) string {
 	return ret
 }
 
+// describeRoute set input and output types of a route registered in group
+func (g *group) describeRoute(method, path string, in, out reflect.Type) {
+	g.server.setRouteTypes(method, joinPath(g.base, path), in, out)
+}
+
 func (g *group) wrap(handler HandlerFunc) HandlerFunc {
 	return g.interceptors.Wrap(handler)
 }
@@ -21,6 +21,7 @@ func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // WrapF wrap a http handlerfunc into HandlerFunc
 func WrapF(h http.HandlerFunc) HandlerFunc {
 	return func(ctx Context) {
+		ctx.rewindBody()
 		h(ctx.Rw, ctx.Req)
 	}
 }
@@ -28,8 +29,17 @@ func WrapF(h http.HandlerFunc) HandlerFunc {
 // UnWrapF ...
 func UnWrapF(h HandlerFunc) http.HandlerFunc {
 	return func(rw http.ResponseWriter, req *http.Request) {
+		_, tracked := rw.(ResponseWriter)
 		ctx := getContext(rw, req)
+		if !tracked {
+			deferHeader(ctx.Rw)
+		}
 		h(ctx)
+		// send the header of the writer created for h
+		if w, ok := ctx.Rw.(*responseWriter); ok && !tracked {
+			handlerReturned(w)
+			w.commit()
+		}
 	}
 }
 
@@ -0,0 +1,356 @@
+// Package hub fan out messages to SSE and WebSocket clients subscribed to topics
+package hub
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/philchia/zen"
+)
+
+// Policy decide what to do with a slow subscriber whose send buffer is full
+type Policy uint8
+
+const (
+	// PolicyDrop drop messages for slow subscribers
+	PolicyDrop Policy = iota
+	// PolicyDisconnect unsubscribe and close slow subscribers
+	PolicyDisconnect
+)
+
+// DefaultBufferSize is the default number of messages buffered for each subscriber
+const DefaultBufferSize = 16
+
+// DefaultCloseTimeout is the default time Close wait for subscribers to drain their buffers
+const DefaultCloseTimeout = 5 * time.Second
+
+// Message is published to subscribers of a topic
+type Message struct {
+	Topic string      `json:"topic"`
+	Event string      `json:"event,omitempty"`
+	ID    string      `json:"id,omitempty"`
+	Data  interface{} `json:"data"`
+}
+
+// Subscriber deliver messages to a client
+type Subscriber interface {
+	// Send deliver msg, an error unsubscribe the subscriber
+	Send(msg Message) error
+	// Close is called once the subscriber is unsubscribed and its buffer is drained
+	Close() error
+}
+
+// Metrics of a hub
+type Metrics struct {
+	// Subscribers is the number of current subscribers
+	Subscribers int
+	// Topics is the number of subscribers of every topic
+	Topics map[string]int
+	// Published is the number of messages queued to subscribers
+	Published uint64
+	// Dropped is the number of messages dropped for slow subscribers
+	Dropped uint64
+	// Disconnected is the number of slow subscribers disconnected
+	Disconnected uint64
+}
+
+// Option customize Hub
+type Option func(*Hub)
+
+// SetBufferSize return Option for set the number of messages buffered for each subscriber
+func SetBufferSize(n int) Option {
+	return func(h *Hub) {
+		h.bufferSize = n
+	}
+}
+
+// SetPolicy return Option for set the policy of slow subscribers
+func SetPolicy(policy Policy) Option {
+	return func(h *Hub) {
+		h.policy = policy
+	}
+}
+
+// SetCloseTimeout return Option for set the time Close wait for subscribers to drain their buffers
+func SetCloseTimeout(d time.Duration) Option {
+	return func(h *Hub) {
+		h.closeTimeout = d
+	}
+}
+
+// Hub hold subscribers of topics
+type Hub struct {
+	bufferSize   int
+	policy       Policy
+	closeTimeout time.Duration
+
+	mu      sync.RWMutex
+	closed  bool
+	clients map[*Client]struct{}
+	topics  map[string]map[*Client]struct{}
+
+	published    uint64
+	dropped      uint64
+	disconnected uint64
+}
+
+// New create a Hub
+func New(options ...Option) *Hub {
+	h := &Hub{
+		bufferSize:   DefaultBufferSize,
+		policy:       PolicyDrop,
+		closeTimeout: DefaultCloseTimeout,
+		clients:      make(map[*Client]struct{}),
+		topics:       make(map[string]map[*Client]struct{}),
+	}
+	for _, option := range options {
+		option(h)
+	}
+	return h
+}
+
+// Attach close the hub when server shutdown, so subscribers are drained and
+// closed before the server close open connections, see Close
+func (h *Hub) Attach(s *zen.Server) {
+	s.OnShutdown(h.Close)
+}
+
+// Client is a subscription of a Subscriber
+type Client struct {
+	hub    *Hub
+	sub    Subscriber
+	send   chan Message
+	topics map[string]struct{}
+	done   chan struct{}
+	// closeOnce guard sub.Close which is called by run or forced by CloseContext
+	closeOnce sync.Once
+}
+
+// Subscribe add sub to topics, messages are delivered to sub in its own goroutine
+func (h *Hub) Subscribe(sub Subscriber, topics ...string) *Client {
+	c := &Client{
+		hub:    h,
+		sub:    sub,
+		send:   make(chan Message, h.bufferSize),
+		topics: make(map[string]struct{}),
+		done:   make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		close(c.send)
+		go c.run()
+		return c
+	}
+	h.clients[c] = struct{}{}
+	for _, topic := range topics {
+		h.join(c, topic)
+	}
+	h.mu.Unlock()
+
+	go c.run()
+	return c
+}
+
+// Unsubscribe remove c from all topics and wait until its buffer is drained and it is closed
+func (h *Hub) Unsubscribe(c *Client) {
+	h.remove(c)
+	<-c.done
+}
+
+// remove remove c from hub and stop its goroutine after draining the buffer
+func (h *Hub) remove(c *Client) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[c]; !ok {
+		return false
+	}
+	delete(h.clients, c)
+	for topic := range c.topics {
+		h.leave(c, topic)
+	}
+	close(c.send)
+	return true
+}
+
+func (h *Hub) join(c *Client, topic string) {
+	subs, ok := h.topics[topic]
+	if !ok {
+		subs = make(map[*Client]struct{})
+		h.topics[topic] = subs
+	}
+	subs[c] = struct{}{}
+	c.topics[topic] = struct{}{}
+}
+
+func (h *Hub) leave(c *Client, topic string) {
+	delete(c.topics, topic)
+	if subs, ok := h.topics[topic]; ok {
+		delete(subs, c)
+		if len(subs) == 0 {
+			delete(h.topics, topic)
+		}
+	}
+}
+
+// Publish queue msg to subscribers of topic, and return the number of subscribers it is queued to
+func (h *Hub) Publish(topic string, msg Message) int {
+	msg.Topic = topic
+	h.mu.RLock()
+	subs := h.topics[topic]
+	clients := make([]*Client, 0, len(subs))
+	for c := range subs {
+		clients = append(clients, c)
+	}
+	n, slow := h.deliver(clients, msg)
+	h.mu.RUnlock()
+
+	h.disconnect(slow)
+	return n
+}
+
+// Broadcast queue msg to all subscribers, and return the number of subscribers it is queued to
+func (h *Hub) Broadcast(msg Message) int {
+	h.mu.RLock()
+	clients := make([]*Client, 0, len(h.clients))
+	for c := range h.clients {
+		clients = append(clients, c)
+	}
+	n, slow := h.deliver(clients, msg)
+	h.mu.RUnlock()
+
+	h.disconnect(slow)
+	return n
+}
+
+// deliver queue msg to clients without blocking, must be called with read lock held
+func (h *Hub) deliver(clients []*Client, msg Message) (n int, slow []*Client) {
+	for _, c := range clients {
+		select {
+		case c.send <- msg:
+			n++
+		default:
+			atomic.AddUint64(&h.dropped, 1)
+			if h.policy == PolicyDisconnect {
+				slow = append(slow, c)
+			}
+		}
+	}
+	atomic.AddUint64(&h.published, uint64(n))
+	return n, slow
+}
+
+// disconnect remove slow clients
+func (h *Hub) disconnect(slow []*Client) {
+	for _, c := range slow {
+		if h.remove(c) {
+			atomic.AddUint64(&h.disconnected, 1)
+		}
+	}
+}
+
+// Metrics return the current metrics of hub
+func (h *Hub) Metrics() Metrics {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	m := Metrics{
+		Subscribers:  len(h.clients),
+		Topics:       make(map[string]int, len(h.topics)),
+		Published:    atomic.LoadUint64(&h.published),
+		Dropped:      atomic.LoadUint64(&h.dropped),
+		Disconnected: atomic.LoadUint64(&h.disconnected),
+	}
+	for topic, subs := range h.topics {
+		m.Topics[topic] = len(subs)
+	}
+	return m
+}
+
+// Close unsubscribe all subscribers, and wait until their buffers are drained and
+// they are closed, at most the timeout set by SetCloseTimeout, see CloseContext
+func (h *Hub) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), h.closeTimeout)
+	defer cancel()
+	h.CloseContext(ctx)
+}
+
+// CloseContext unsubscribe all subscribers, and wait until their buffers are drained
+// and they are closed or ctx is done. Subscribers which are not drained when ctx is
+// done are closed without waiting, so a stalled client can not block shutdown,
+// and ctx.Err() is returned.
+func (h *Hub) CloseContext(ctx context.Context) error {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return nil
+	}
+	h.closed = true
+	clients := make([]*Client, 0, len(h.clients))
+	for c := range h.clients {
+		clients = append(clients, c)
+	}
+	h.mu.Unlock()
+
+	for _, c := range clients {
+		h.remove(c)
+	}
+	for i, c := range clients {
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			// Close of a stalled subscriber may block too
+			for _, c := range clients[i:] {
+				go c.close()
+			}
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Join add c to topic
+func (c *Client) Join(topic string) {
+	c.hub.mu.Lock()
+	defer c.hub.mu.Unlock()
+	if _, ok := c.hub.clients[c]; ok {
+		c.hub.join(c, topic)
+	}
+}
+
+// Leave remove c from topic
+func (c *Client) Leave(topic string) {
+	c.hub.mu.Lock()
+	defer c.hub.mu.Unlock()
+	c.hub.leave(c, topic)
+}
+
+// Done return a channel which is closed once c is unsubscribed and closed
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// run deliver queued messages until c is unsubscribed
+func (c *Client) run() {
+	defer close(c.done)
+	for msg := range c.send {
+		if err := c.sub.Send(msg); err != nil {
+			c.hub.remove(c)
+			// discard the rest
+			for range c.send {
+			}
+			break
+		}
+	}
+	c.close()
+}
+
+// close close the subscriber once
+func (c *Client) close() {
+	c.closeOnce.Do(func() {
+		c.sub.Close()
+	})
+}
@@ -0,0 +1,200 @@
+package hub
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/philchia/zen"
+)
+
+// recorder is a Subscriber which record messages, Send block until release is closed
+type recorder struct {
+	mu       sync.Mutex
+	messages []Message
+	closed   bool
+	release  chan struct{}
+}
+
+func newRecorder(block bool) *recorder {
+	r := &recorder{release: make(chan struct{})}
+	if !block {
+		close(r.release)
+	}
+	return r
+}
+
+func (r *recorder) Send(msg Message) error {
+	<-r.release
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, msg)
+	return nil
+}
+
+func (r *recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return nil
+}
+
+func (r *recorder) received() ([]Message, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Message(nil), r.messages...), r.closed
+}
+
+func TestHub_Publish(t *testing.T) {
+	h := New()
+	a, b := newRecorder(false), newRecorder(false)
+	ca := h.Subscribe(a, "news", "sport")
+	h.Subscribe(b, "news")
+
+	tests := []struct {
+		topic string
+		want  int
+	}{
+		{"news", 2},
+		{"sport", 1},
+		{"weather", 0},
+	}
+	for _, tt := range tests {
+		if n := h.Publish(tt.topic, Message{Data: tt.topic}); n != tt.want {
+			t.Errorf("Hub.Publish(%q) = %d, want %d", tt.topic, n, tt.want)
+		}
+	}
+
+	ca.Leave("sport")
+	if n := h.Publish("sport", Message{}); n != 0 {
+		t.Errorf("Hub.Publish() after Leave = %d, want 0", n)
+	}
+	ca.Join("weather")
+	if n := h.Broadcast(Message{Data: "all"}); n != 2 {
+		t.Errorf("Hub.Broadcast() = %d, want 2", n)
+	}
+
+	m := h.Metrics()
+	if m.Subscribers != 2 || m.Topics["news"] != 2 || m.Topics["weather"] != 1 || m.Published != 5 {
+		t.Errorf("Hub.Metrics() = %+v", m)
+	}
+
+	h.Close()
+	messages, closed := a.received()
+	if !closed || len(messages) != 3 || messages[0].Topic != "news" || messages[1].Topic != "sport" {
+		t.Errorf("received = %+v closed = %v", messages, closed)
+	}
+	if n := h.Publish("news", Message{}); n != 0 {
+		t.Errorf("Hub.Publish() after Close = %d, want 0", n)
+	}
+	if m := h.Metrics(); m.Subscribers != 0 || len(m.Topics) != 0 {
+		t.Errorf("Hub.Metrics() after Close = %+v", m)
+	}
+}
+
+func TestHub_policy(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       Policy
+		subscribers  int
+		dropped      uint64
+		disconnected uint64
+	}{
+		{"drop", PolicyDrop, 1, 3, 0},
+		{"disconnect", PolicyDisconnect, 0, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := New(SetBufferSize(1), SetPolicy(tt.policy))
+			r := newRecorder(true)
+			c := h.Subscribe(r, "topic")
+
+			// the first message may be taken by the goroutine before it block
+			h.Publish("topic", Message{})
+			time.Sleep(10 * time.Millisecond)
+			for i := 0; i < 4; i++ {
+				h.Publish("topic", Message{})
+			}
+
+			m := h.Metrics()
+			if m.Subscribers != tt.subscribers || m.Dropped != tt.dropped || m.Disconnected != tt.disconnected {
+				t.Errorf("Hub.Metrics() = %+v", m)
+			}
+
+			close(r.release)
+			h.Close()
+			<-c.Done()
+			if _, closed := r.received(); !closed {
+				t.Error("subscriber is not closed")
+			}
+		})
+	}
+}
+
+func TestHub_ServeSSE(t *testing.T) {
+	h := New()
+	server := zen.New()
+	h.Attach(server)
+	served := make(chan struct{})
+	server.Get("/events", func(ctx zen.Context) {
+		h.ServeSSE(ctx, "news")
+		close(served)
+	})
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	for h.Metrics().Topics["news"] != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	h.Publish("news", Message{Event: "headline", Data: "hello"})
+	server.Shutdown()
+
+	var lines []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if got := strings.Join(lines, "\n"); got != "event: headline\ndata: hello\n" {
+		t.Errorf("ServeSSE() body = %q", got)
+	}
+	<-served
+}
+
+func TestHub_CloseContext(t *testing.T) {
+	h := New()
+	stalled, drained := newRecorder(true), newRecorder(false)
+	h.Subscribe(stalled, "news")
+	h.Subscribe(drained, "news")
+	h.Publish("news", Message{Data: "stuck"})
+	defer close(stalled.release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := h.CloseContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Hub.CloseContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if _, closed := drained.received(); !closed {
+		t.Error("Hub.CloseContext() did not close drained subscriber")
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, closed := stalled.received(); closed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Hub.CloseContext() did not force close stalled subscriber")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,87 @@
+package hub
+
+import (
+	"github.com/philchia/zen"
+)
+
+// streamSubscriber deliver messages as server-sent events
+type streamSubscriber struct {
+	stream *zen.EventStream
+}
+
+// Stream return a Subscriber which send messages as events of stream
+func Stream(stream *zen.EventStream) Subscriber {
+	return &streamSubscriber{stream: stream}
+}
+
+// Send write msg as an event
+func (s *streamSubscriber) Send(msg Message) error {
+	return s.stream.Send(msg.Event, msg.ID, msg.Data)
+}
+
+// Close close the stream
+func (s *streamSubscriber) Close() error {
+	s.stream.Close()
+	return nil
+}
+
+// webSocketSubscriber deliver messages as json text messages
+type webSocketSubscriber struct {
+	ws *zen.WebSocket
+}
+
+// WebSocket return a Subscriber which send messages as json text messages of ws,
+// ws is closed with CloseGoingAway when unsubscribed
+func WebSocket(ws *zen.WebSocket) Subscriber {
+	return &webSocketSubscriber{ws: ws}
+}
+
+// Send write msg as json
+func (s *webSocketSubscriber) Send(msg Message) error {
+	return s.ws.WriteJSON(msg)
+}
+
+// Close start the close handshake
+func (s *webSocketSubscriber) Close() error {
+	return s.ws.Close(zen.CloseGoingAway, "")
+}
+
+// ServeSSE start an event stream, subscribe it to topics, and block until the
+// client disconnect or the stream is unsubscribed
+func (h *Hub) ServeSSE(ctx zen.Context, topics ...string) error {
+	stream, err := ctx.SSE()
+	if err != nil {
+		return err
+	}
+
+	c := h.Subscribe(Stream(stream), topics...)
+	select {
+	case <-stream.Done():
+	case <-c.Done():
+	}
+	h.Unsubscribe(c)
+	return nil
+}
+
+// ServeWebSocket upgrade the connection, subscribe it to topics, and block until
+// the connection is closed. Messages received from the client are passed to
+// handle if it is not nil, handle may Join or Leave topics with c.
+func (h *Hub) ServeWebSocket(ctx zen.Context, handle func(c *Client, messageType int, data []byte), topics ...string) error {
+	ws, err := ctx.Upgrade()
+	if err != nil {
+		return err
+	}
+
+	c := h.Subscribe(WebSocket(ws), topics...)
+	for {
+		messageType, data, err := ws.ReadMessage()
+		if err != nil {
+			break
+		}
+		if handle != nil {
+			handle(c, messageType, data)
+		}
+	}
+	h.Unsubscribe(c)
+	return nil
+}
@@ -0,0 +1,363 @@
+// Package i18n load message catalogs and negotiate the locale of requests
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/philchia/zen"
+)
+
+// Plural forms of a message, as CLDR plural categories
+const (
+	Zero  = "zero"
+	One   = "one"
+	Two   = "two"
+	Few   = "few"
+	Many  = "many"
+	Other = "other"
+)
+
+// DefaultParam is the default name of the query param and cookie which select a locale
+const DefaultParam = "lang"
+
+// message is a catalog entry, plain messages only have the Other form
+type message map[string]string
+
+// Option customize Bundle
+type Option func(*Bundle)
+
+// SetQueryParam return Option for set the query param which select a locale, empty to disable
+func SetQueryParam(name string) Option {
+	return func(b *Bundle) {
+		b.queryParam = name
+	}
+}
+
+// SetCookie return Option for set the cookie which select a locale, empty to disable
+func SetCookie(name string) Option {
+	return func(b *Bundle) {
+		b.cookie = name
+	}
+}
+
+// SetPluralRule return Option for set the plural rule of a language, e.g. "pt"
+func SetPluralRule(lang string, rule PluralRule) Option {
+	return func(b *Bundle) {
+		b.rules[strings.ToLower(lang)] = rule
+	}
+}
+
+// Bundle hold message catalogs of locales
+type Bundle struct {
+	fallback   string
+	queryParam string
+	cookie     string
+	rules      map[string]PluralRule
+	catalogs   map[string]map[string]message
+	// locales are supported locales as they are added
+	locales []string
+}
+
+// New create a Bundle, messages missing in a locale are taken from fallback locale
+func New(fallback string, options ...Option) *Bundle {
+	b := &Bundle{
+		fallback:   fallback,
+		queryParam: DefaultParam,
+		cookie:     DefaultParam,
+		rules:      make(map[string]PluralRule),
+		catalogs:   make(map[string]map[string]message),
+	}
+	for _, option := range options {
+		option(b)
+	}
+	return b
+}
+
+// LoadDir load catalogs of dir, see LoadFS
+func (b *Bundle) LoadDir(dir string) error {
+	return b.LoadFS(os.DirFS(dir))
+}
+
+// LoadFS load catalogs named by their locale, e.g. en.json or pt-BR.toml, from fsys.
+// Nested tables are flattened into dotted keys, and a table with only plural
+// categories as keys, e.g. {"one": "%d item", "other": "%d items"}, is a plural message.
+func (b *Bundle) LoadFS(fsys fs.FS) error {
+	return fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		ext := path.Ext(p)
+		if ext != ".json" && ext != ".toml" {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+
+		var messages map[string]interface{}
+		if ext == ".json" {
+			err = json.Unmarshal(data, &messages)
+		} else {
+			messages, err = parseTOML(string(data))
+		}
+		if err != nil {
+			return fmt.Errorf("i18n: %s: %w", p, err)
+		}
+		return b.AddMessages(strings.TrimSuffix(path.Base(p), ext), messages)
+	})
+}
+
+// AddMessages add messages of locale, values are strings, or maps of nested messages or plural forms
+func (b *Bundle) AddMessages(locale string, messages map[string]interface{}) error {
+	tag := strings.ToLower(locale)
+	catalog, ok := b.catalogs[tag]
+	if !ok {
+		catalog = make(map[string]message)
+		b.catalogs[tag] = catalog
+		b.locales = append(b.locales, locale)
+	}
+	return addMessages(catalog, "", messages)
+}
+
+// addMessages flatten messages into catalog with prefix
+func addMessages(catalog map[string]message, prefix string, messages map[string]interface{}) error {
+	for key, value := range messages {
+		key = prefix + key
+		switch v := value.(type) {
+		case string:
+			catalog[key] = message{Other: v}
+		case map[string]interface{}:
+			if forms, ok := pluralForms(v); ok {
+				catalog[key] = forms
+				continue
+			}
+			if err := addMessages(catalog, key+".", v); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("i18n: invalid message %q of type %T", key, value)
+		}
+	}
+	return nil
+}
+
+// pluralForms return v as plural forms if all its keys are plural categories with string values
+func pluralForms(v map[string]interface{}) (message, bool) {
+	forms := make(message, len(v))
+	for key, value := range v {
+		s, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		switch key {
+		case Zero, One, Two, Few, Many, Other:
+			forms[key] = s
+		default:
+			return nil, false
+		}
+	}
+	_, ok := forms[Other]
+	return forms, ok
+}
+
+// Locales return supported locales
+func (b *Bundle) Locales() []string {
+	locales := make([]string, len(b.locales))
+	copy(locales, b.locales)
+	return locales
+}
+
+// supported return the supported locale matching tag case-insensitively
+func (b *Bundle) supported(tag string) (string, bool) {
+	for _, locale := range b.locales {
+		if strings.EqualFold(locale, tag) {
+			return locale, true
+		}
+	}
+	return "", false
+}
+
+// Negotiate return the locale of request, selected by query param, cookie, or
+// Accept-Language header in order, the fallback locale is returned if none is supported
+func (b *Bundle) Negotiate(ctx zen.Context) string {
+	if b.queryParam != "" {
+		if locale, ok := b.supported(ctx.Req.URL.Query().Get(b.queryParam)); ok {
+			return locale
+		}
+	}
+	if b.cookie != "" {
+		if cookie, err := ctx.Req.Cookie(b.cookie); err == nil {
+			if locale, ok := b.supported(cookie.Value); ok {
+				return locale
+			}
+		}
+	}
+	if locale, ok := b.matchAcceptLanguage(ctx.Req.Header.Get("Accept-Language")); ok {
+		return locale
+	}
+	return b.fallback
+}
+
+// languageRange is a range of Accept-Language header
+type languageRange struct {
+	tag string
+	q   float64
+}
+
+// matchAcceptLanguage return the supported locale best matching header, an exact match
+// is preferred over a locale of the same language, e.g. en-GB for en-US
+func (b *Bundle) matchAcceptLanguage(header string) (string, bool) {
+	var ranges []languageRange
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		r := languageRange{tag: strings.TrimSpace(fields[0]), q: 1}
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				q, err := strconv.ParseFloat(param[2:], 64)
+				if err != nil {
+					q = 0
+				}
+				r.q = q
+			}
+		}
+		if r.tag != "" && r.tag != "*" && r.q > 0 {
+			ranges = append(ranges, r)
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+
+	for _, r := range ranges {
+		if locale, ok := b.supported(r.tag); ok {
+			return locale, true
+		}
+		lang := language(r.tag)
+		for _, locale := range b.locales {
+			if strings.EqualFold(language(locale), lang) {
+				return locale, true
+			}
+		}
+	}
+	return "", false
+}
+
+// language return the primary language subtag of tag in lower case
+func language(tag string) string {
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	return strings.ToLower(tag)
+}
+
+// Localizer translate messages into a locale
+type Localizer struct {
+	locale string
+	// chain is the catalogs looked up in order
+	chain []map[string]message
+	rule  PluralRule
+}
+
+// Localizer return a Localizer of locale, messages are looked up in locale,
+// its language, and the fallback locale in order
+func (b *Bundle) Localizer(locale string) *Localizer {
+	l := &Localizer{locale: locale, rule: b.pluralRule(locale)}
+	seen := make(map[string]bool)
+	for _, tag := range []string{locale, language(locale), b.fallback, language(b.fallback)} {
+		tag = strings.ToLower(tag)
+		if catalog, ok := b.catalogs[tag]; ok && !seen[tag] {
+			seen[tag] = true
+			l.chain = append(l.chain, catalog)
+		}
+	}
+	return l
+}
+
+// Locale return the locale of l
+func (l *Localizer) Locale() string {
+	return l.locale
+}
+
+// Translate return the message of key formatted with args, messages without
+// formatting verbs are returned as they are. The plural form of a plural message
+// is selected by the first integer in args, and explicit zero forms are used for
+// 0 in every language.
+func (l *Localizer) Translate(key string, args ...interface{}) (string, bool) {
+	for _, catalog := range l.chain {
+		msg, ok := catalog[key]
+		if !ok {
+			continue
+		}
+		text := msg[Other]
+		if len(msg) > 1 {
+			if n, ok := firstInteger(args); ok {
+				text = msg.form(l.rule, n)
+			}
+		}
+		// forms like "no items" may not use args
+		if len(args) == 0 || !strings.Contains(text, "%") {
+			return text, true
+		}
+		return fmt.Sprintf(text, args...), true
+	}
+	return "", false
+}
+
+// form return the plural form for n
+func (m message) form(rule PluralRule, n int64) string {
+	if text, ok := m[Zero]; ok && n == 0 {
+		return text
+	}
+	if text, ok := m[rule(n)]; ok {
+		return text
+	}
+	return m[Other]
+}
+
+// firstInteger return the first integer of args
+func firstInteger(args []interface{}) (int64, bool) {
+	for _, arg := range args {
+		v := reflect.ValueOf(arg)
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return v.Int(), true
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return int64(v.Uint()), true
+		}
+	}
+	return 0, false
+}
+
+// Middleware return a zen.Middleware which negotiate the locale of requests and set a
+// Localizer of it as the translator of Context, so Context.T and validation errors are
+// localized. Content-Language is set to the negotiated locale.
+func (b *Bundle) Middleware() zen.Middleware {
+	return func(next zen.HandlerFunc) zen.HandlerFunc {
+		return func(ctx zen.Context) {
+			locale := b.Negotiate(ctx)
+			header := ctx.Rw.Header()
+			header.Add(zen.HeaderVary, "Accept-Language")
+			header.Set("Content-Language", locale)
+			ctx.SetTranslator(b.Localizer(locale))
+			next(ctx)
+		}
+	}
+}
+
+// Locale return the locale of the Localizer set on ctx by Middleware, or an empty string
+func Locale(ctx zen.Context) string {
+	if l, ok := ctx.Translator().(*Localizer); ok {
+		return l.locale
+	}
+	return ""
+}
@@ -0,0 +1,202 @@
+package i18n
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/philchia/zen"
+)
+
+func newTestBundle(t *testing.T) *Bundle {
+	b := New("en")
+	err := b.LoadFS(fstest.MapFS{
+		"en.json": {Data: []byte(`{
+			"hello": "Hello, %s!",
+			"items": {"zero": "no items", "one": "%d item", "other": "%d items"},
+			"errors": {"name_required": "name is required"}
+		}`)},
+		"fr.toml": {Data: []byte(`
+# French
+hello = "Bonjour, %s !"
+
+[items]
+one = "%d article"  # 0 and 1
+other = '%d articles'
+
+[errors]
+name_required = "le nom est obligatoire"
+`)},
+		"ru.json":    {Data: []byte(`{"items": {"one": "%d предмет", "few": "%d предмета", "many": "%d предметов", "other": "%d предмета"}}`)},
+		"en-GB.json": {Data: []byte(`{"color": "colour"}`)},
+		"README.md":  {Data: []byte(`ignored`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestLocalizer_Translate(t *testing.T) {
+	b := newTestBundle(t)
+	tests := []struct {
+		locale string
+		key    string
+		args   []interface{}
+		want   string
+		ok     bool
+	}{
+		{"en", "hello", []interface{}{"zen"}, "Hello, zen!", true},
+		{"fr", "hello", []interface{}{"zen"}, "Bonjour, zen !", true},
+		{"en", "items", []interface{}{0}, "no items", true},
+		{"en", "items", []interface{}{1}, "1 item", true},
+		{"en", "items", []interface{}{2}, "2 items", true},
+		{"fr", "items", []interface{}{0}, "0 article", true},
+		{"fr", "items", []interface{}{2}, "2 articles", true},
+		{"ru", "items", []interface{}{21}, "21 предмет", true},
+		{"ru", "items", []interface{}{3}, "3 предмета", true},
+		{"ru", "items", []interface{}{11}, "11 предметов", true},
+		{"en-GB", "color", nil, "colour", true},
+		// falls back to language then fallback locale
+		{"en-GB", "errors.name_required", nil, "name is required", true},
+		{"fr-CA", "errors.name_required", nil, "le nom est obligatoire", true},
+		{"ru", "hello", []interface{}{"zen"}, "Hello, zen!", true},
+		{"en", "missing", nil, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := b.Localizer(tt.locale).Translate(tt.key, tt.args...)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Localizer(%s).Translate(%q, %v) = %q, %v, want %q, %v", tt.locale, tt.key, tt.args, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestBundle_Negotiate(t *testing.T) {
+	b := newTestBundle(t)
+	tests := []struct {
+		name           string
+		query          string
+		cookie         string
+		acceptLanguage string
+		want           string
+	}{
+		{"default", "", "", "", "en"},
+		{"accept", "", "", "fr-FR, en;q=0.5", "fr"},
+		{"q values", "", "", "en;q=0.5, ru;q=0.8", "ru"},
+		{"exact region", "", "", "en-gb", "en-GB"},
+		{"unsupported", "", "", "de, ja;q=0.9", "en"},
+		{"excluded", "", "", "fr;q=0, en-GB;q=0.1", "en-GB"},
+		{"cookie", "", "ru", "fr", "ru"},
+		{"query", "FR", "ru", "en", "fr"},
+		{"unsupported query", "de", "", "ru", "ru"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/?lang="+tt.query, nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: DefaultParam, Value: tt.cookie})
+			}
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			if got := b.Negotiate(zen.Context{Req: req}); got != tt.want {
+				t.Errorf("Bundle.Negotiate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBundle_Middleware(t *testing.T) {
+	type input struct {
+		Name string `json:"name" valid:"^.+$" msg:"errors.name_required"`
+	}
+	b := newTestBundle(t)
+	server := zen.New()
+	server.AddInterceptor(b.Middleware())
+	server.Get("/items", func(ctx zen.Context) {
+		ctx.WriteString(Locale(ctx) + ": " + ctx.T("items", 3) + ", " + ctx.T("not a key", 1))
+	})
+	server.Post("/users", zen.Handle(func(ctx zen.Context, in input) (struct{}, error) {
+		return struct{}{}, nil
+	}))
+
+	tests := []struct {
+		name           string
+		method, path   string
+		acceptLanguage string
+		code           int
+		want           string
+	}{
+		{"translate", "GET", "/items", "fr", zen.StatusOK, "fr: 3 articles, not a key"},
+		{"validation", "POST", "/users", "fr", zen.StatusUnprocessableEntity, "le nom est obligatoire"},
+		{"validation fallback", "POST", "/users", "ja", zen.StatusUnprocessableEntity, "name is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{"name": ""}`))
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			rw := httptest.NewRecorder()
+			server.ServeHTTP(rw, req)
+
+			if rw.Code != tt.code {
+				t.Fatalf("%s %s code = %d, want %d", tt.method, tt.path, rw.Code, tt.code)
+			}
+			got := rw.Body.String()
+			if rw.Code != zen.StatusOK {
+				var problem zen.Problem
+				json.Unmarshal(rw.Body.Bytes(), &problem)
+				got = problem.Detail
+			}
+			if got != tt.want {
+				t.Errorf("%s %s body = %q, want %q", tt.method, tt.path, got, tt.want)
+			}
+			if vary := rw.Header().Get(zen.HeaderVary); vary != "Accept-Language" {
+				t.Errorf("%s %s Vary = %q, want Accept-Language", tt.method, tt.path, vary)
+			}
+		})
+	}
+}
+
+func TestParseTOML(t *testing.T) {
+	got, err := parseTOML(`
+"a.b" = "quoted"
+c."d.e" . 'f=g' = "dotted" # comment
+[ "h.i" . j ]
+k = 'table'
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"a.b": "quoted",
+		"c":   map[string]interface{}{"d.e": map[string]interface{}{"f=g": "dotted"}},
+		"h.i": map[string]interface{}{"j": map[string]interface{}{"k": "table"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTOML() = %v, want %v", got, want)
+	}
+}
+
+func TestParseTOML_errors(t *testing.T) {
+	tests := []string{
+		`key`,
+		`key = 1`,
+		`key = "unterminated`,
+		`key = "a" b`,
+		`[table`,
+		"a = \"x\"\n[a]",
+		`"a.b = "x"`,
+		`a. = "x"`,
+		`a b = "x"`,
+		`["a"`,
+	}
+	for _, data := range tests {
+		if _, err := parseTOML(data); err == nil {
+			t.Errorf("parseTOML(%q) error = nil", data)
+		}
+	}
+}
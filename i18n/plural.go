@@ -0,0 +1,117 @@
+package i18n
+
+// PluralRule return the plural category of n
+type PluralRule func(n int64) string
+
+// pluralRule return the rule of locale's language
+func (b *Bundle) pluralRule(locale string) PluralRule {
+	lang := language(locale)
+	if rule, ok := b.rules[lang]; ok {
+		return rule
+	}
+	if rule, ok := pluralRules[lang]; ok {
+		return rule
+	}
+	return pluralOne
+}
+
+// pluralRules are CLDR rules for integers of common languages,
+// languages not listed use pluralOne
+var pluralRules = map[string]PluralRule{
+	"fr": pluralZeroOne,
+	"pt": pluralZeroOne,
+	"ja": pluralNone,
+	"ko": pluralNone,
+	"zh": pluralNone,
+	"th": pluralNone,
+	"vi": pluralNone,
+	"id": pluralNone,
+	"ru": pluralSlavic,
+	"uk": pluralSlavic,
+	"be": pluralSlavic,
+	"pl": pluralPolish,
+	"cs": pluralCzech,
+	"sk": pluralCzech,
+	"ar": pluralArabic,
+}
+
+// pluralOne: one for 1, e.g. English and German
+func pluralOne(n int64) string {
+	if n == 1 {
+		return One
+	}
+	return Other
+}
+
+// pluralZeroOne: one for 0 and 1, e.g. French
+func pluralZeroOne(n int64) string {
+	if n == 0 || n == 1 {
+		return One
+	}
+	return Other
+}
+
+// pluralNone: no plural forms, e.g. Japanese and Chinese
+func pluralNone(n int64) string {
+	return Other
+}
+
+// pluralSlavic: Russian and Ukrainian
+func pluralSlavic(n int64) string {
+	n = abs(n)
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return One
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return Few
+	}
+	return Many
+}
+
+// pluralPolish: Polish
+func pluralPolish(n int64) string {
+	n = abs(n)
+	switch {
+	case n == 1:
+		return One
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return Few
+	}
+	return Many
+}
+
+// pluralCzech: Czech and Slovak
+func pluralCzech(n int64) string {
+	switch {
+	case n == 1:
+		return One
+	case n >= 2 && n <= 4:
+		return Few
+	}
+	return Other
+}
+
+// pluralArabic: Arabic
+func pluralArabic(n int64) string {
+	n = abs(n)
+	switch {
+	case n == 0:
+		return Zero
+	case n == 1:
+		return One
+	case n == 2:
+		return Two
+	case n%100 >= 3 && n%100 <= 10:
+		return Few
+	case n%100 >= 11:
+		return Many
+	}
+	return Other
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,172 @@
+package i18n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseTOML parse the subset of TOML used by catalogs: tables, bare, quoted and
+// dotted keys, and single line basic or literal strings
+func parseTOML(data string) (map[string]interface{}, error) {
+	root := make(map[string]interface{})
+	table := root
+
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			if strings.HasPrefix(line, "[[") {
+				return nil, fmt.Errorf("line %d: invalid table header", i+1)
+			}
+			keys, rest, err := parseKey(line[1:], ']')
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			if !isComment(rest) {
+				return nil, fmt.Errorf("line %d: invalid table header", i+1)
+			}
+			if table, err = subTable(root, keys); err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			continue
+		}
+
+		keys, rest, err := parseKey(line, '=')
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		value, err := parseString(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		parent, err := subTable(table, keys[:len(keys)-1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		parent[keys[len(keys)-1]] = value
+	}
+	return root, nil
+}
+
+// parseKey parse a bare, quoted or dotted key at the beginning of s, which is
+// followed by end, and return the rest of s after end. Dots and end inside quoted
+// keys are part of the key, e.g. "a.b" = "x" is a single key.
+func parseKey(s string, end byte) ([]string, string, error) {
+	var keys []string
+	for {
+		s = strings.TrimLeft(s, " \t")
+		var key string
+		switch {
+		case s == "":
+			return nil, "", fmt.Errorf("expected key followed by %q", end)
+		case s[0] == '"':
+			n := basicStringLen(s)
+			if n < 0 {
+				return nil, "", fmt.Errorf("unterminated quoted key")
+			}
+			unquoted, err := strconv.Unquote(s[:n])
+			if err != nil {
+				return nil, "", fmt.Errorf("invalid quoted key %s", s[:n])
+			}
+			key, s = unquoted, s[n:]
+		case s[0] == '\'':
+			n := strings.IndexByte(s[1:], '\'')
+			if n < 0 {
+				return nil, "", fmt.Errorf("unterminated quoted key")
+			}
+			key, s = s[1:n+1], s[n+2:]
+		default:
+			n := 0
+			for n < len(s) && isBareKeyChar(s[n]) {
+				n++
+			}
+			if n == 0 {
+				return nil, "", fmt.Errorf("invalid key at %q", s)
+			}
+			key, s = s[:n], s[n:]
+		}
+		keys = append(keys, key)
+
+		s = strings.TrimLeft(s, " \t")
+		switch {
+		case s != "" && s[0] == '.':
+			s = s[1:]
+		case s != "" && s[0] == end:
+			return keys, s[1:], nil
+		default:
+			return nil, "", fmt.Errorf("expected key followed by %q", end)
+		}
+	}
+}
+
+// isBareKeyChar report whether c is allowed in a bare key
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// basicStringLen return the length of the basic string at the beginning of s
+// including quotes, or -1 if it is not terminated
+func basicStringLen(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// parseString parse a basic or literal string followed by an optional comment
+func parseString(s string) (string, error) {
+	if s == "" {
+		return "", fmt.Errorf("missing value")
+	}
+	switch s[0] {
+	case '"':
+		if n := basicStringLen(s); n >= 0 {
+			if !isComment(s[n:]) {
+				return "", fmt.Errorf("unexpected data after string")
+			}
+			return strconv.Unquote(s[:n])
+		}
+	case '\'':
+		if end := strings.IndexByte(s[1:], '\''); end >= 0 {
+			if !isComment(s[end+2:]) {
+				return "", fmt.Errorf("unexpected data after string")
+			}
+			return s[1 : end+1], nil
+		}
+	default:
+		return "", fmt.Errorf("only string values are supported")
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+// isComment report whether s is blank or a comment
+func isComment(s string) bool {
+	s = strings.TrimSpace(s)
+	return s == "" || s[0] == '#'
+}
+
+// subTable return the table of keys under table, creating missing tables
+func subTable(table map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	for _, key := range keys {
+		switch v := table[key].(type) {
+		case nil:
+			sub := make(map[string]interface{})
+			table[key] = sub
+			table = sub
+		case map[string]interface{}:
+			table = v
+		default:
+			return nil, fmt.Errorf("key %q is not a table", key)
+		}
+	}
+	return table, nil
+}
@@ -0,0 +1,385 @@
+// Package compress provide a zen middleware which compress responses with gzip or deflate
+package compress
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/philchia/zen"
+)
+
+const (
+	// EncodingGzip is the gzip content coding
+	EncodingGzip = "gzip"
+	// EncodingDeflate is the deflate content coding
+	EncodingDeflate = "deflate"
+
+	// DefaultMinLength is the default min length of compressed responses
+	DefaultMinLength = 1024
+)
+
+// DefaultContentTypes is the default allow list of compressed content types
+var DefaultContentTypes = []string{
+	"text/*",
+	zen.MIMEApplicationJSON,
+	zen.MIMEApplicationJavaScript,
+	zen.MIMEApplicationXML,
+	zen.MIMEApplicationProblemJSON,
+	"application/wasm",
+	"image/svg+xml",
+}
+
+// Option customize the compress middleware
+type Option func(*config)
+
+type config struct {
+	level        int
+	minLength    int
+	contentTypes []string
+}
+
+// SetLevel return Option for set compression level, from flate.BestSpeed to flate.BestCompression
+func SetLevel(level int) Option {
+	return func(c *config) {
+		c.level = level
+	}
+}
+
+// SetMinLength return Option for set min length of compressed responses, smaller responses are sent as they are
+func SetMinLength(n int) Option {
+	return func(c *config) {
+		c.minLength = n
+	}
+}
+
+// SetContentTypes return Option for set allow list of compressed content types,
+// a content type may be a full media type or a wildcard like "text/*"
+func SetContentTypes(types ...string) Option {
+	return func(c *config) {
+		c.contentTypes = types
+	}
+}
+
+// compressor hold pools of writers of a middleware
+type compressor struct {
+	config
+	gzipPool  sync.Pool
+	flatePool sync.Pool
+}
+
+// New return a zen.Middleware which compress responses with gzip or deflate according
+// to Accept-Encoding of the request. Responses are compressed only if their body is
+// at least min length and their content type is in the allow list, responses already
+// encoded, partial responses and responses without body are sent as they are.
+// A strong ETag of a compressed response is made weak.
+// Flush of the response writer flush the compressed data, so streaming responses work.
+func New(options ...Option) zen.Middleware {
+	c := &compressor{
+		config: config{
+			level:        flate.DefaultCompression,
+			minLength:    DefaultMinLength,
+			contentTypes: DefaultContentTypes,
+		},
+	}
+	for _, option := range options {
+		option(&c.config)
+	}
+
+	c.gzipPool.New = func() interface{} {
+		w, err := gzip.NewWriterLevel(io.Discard, c.level)
+		if err != nil {
+			panic(err)
+		}
+		return w
+	}
+	c.flatePool.New = func() interface{} {
+		w, err := flate.NewWriter(io.Discard, c.level)
+		if err != nil {
+			panic(err)
+		}
+		return w
+	}
+	// check level early
+	c.gzipPool.Put(c.gzipPool.New())
+
+	return c.middleware
+}
+
+func (c *compressor) middleware(h zen.HandlerFunc) zen.HandlerFunc {
+	return func(ctx zen.Context) {
+		addVary(ctx.Rw.Header())
+
+		encoding := negotiate(ctx.Req.Header.Get(zen.HeaderAcceptEncoding))
+		if encoding == "" || ctx.Req.Method == zen.HEAD {
+			h(ctx)
+			return
+		}
+
+		w := &writer{ResponseWriter: ctx.Response(), compressor: c, encoding: encoding}
+		ctx.Rw = w
+		defer w.close()
+		h(ctx)
+	}
+}
+
+// addVary add Accept-Encoding to Vary header once
+func addVary(header http.Header) {
+	for _, value := range header.Values(zen.HeaderVary) {
+		for _, field := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), zen.HeaderAcceptEncoding) {
+				return
+			}
+		}
+	}
+	header.Add(zen.HeaderVary, zen.HeaderAcceptEncoding)
+}
+
+// negotiate return the content coding of the highest q value in header,
+// gzip is preferred over deflate with same q value
+func negotiate(header string) string {
+	qs := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(fields[0]))
+		if coding == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				v, err := strconv.ParseFloat(param[2:], 64)
+				if err != nil || v < 0 || v > 1 {
+					v = 0
+				}
+				q = v
+			}
+		}
+		qs[coding] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, coding := range []string{EncodingGzip, EncodingDeflate} {
+		q, ok := qs[coding]
+		if !ok {
+			q = qs["*"]
+		}
+		if q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+// matchContentType report whether contentType matches one of patterns
+func matchContentType(contentType string, patterns []string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if pattern == "*/*" || pattern == mediaType ||
+			strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mediaType, pattern[:len(pattern)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// writer buffer the beginning of the body until it can decide whether to compress
+type writer struct {
+	zen.ResponseWriter
+	*compressor
+	encoding string
+
+	status  int
+	size    int
+	buf     []byte
+	decided bool
+	encoder interface {
+		io.WriteCloser
+		Flush() error
+	}
+}
+
+func (w *writer) WriteHeader(code int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	// informational headers are sent as they are
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.size += len(p)
+	if !w.decided {
+		w.buf = append(w.buf, p...)
+		if len(w.buf) < w.minLength {
+			return len(p), nil
+		}
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if w.encoder != nil {
+		return w.encoder.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *writer) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *writer) ReadFrom(r io.Reader) (int64, error) {
+	// hide ReadFrom so io.Copy use Write
+	return io.Copy(struct{ io.Writer }{w}, r)
+}
+
+// decide start compression if the response is compressible, and write buffered body
+func (w *writer) decide() error {
+	w.decided = true
+	if w.compressible() {
+		header := w.Header()
+		header.Del(zen.HeaderContentLength)
+		header.Set(zen.HeaderContentEncoding, w.encoding)
+		// the compressed body is not byte-for-byte the tagged representation
+		if etag := header.Get(zen.HeaderETag); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set(zen.HeaderETag, "W/"+etag)
+		}
+		switch w.encoding {
+		case EncodingGzip:
+			gw := w.gzipPool.Get().(*gzip.Writer)
+			gw.Reset(w.ResponseWriter)
+			w.encoder = gw
+		case EncodingDeflate:
+			fw := w.flatePool.Get().(*flate.Writer)
+			fw.Reset(w.ResponseWriter)
+			w.encoder = fw
+		}
+	}
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.encoder != nil {
+		_, err = w.encoder.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// compressible report whether the response should be compressed
+func (w *writer) compressible() bool {
+	if len(w.buf) < w.minLength {
+		return false
+	}
+	switch {
+	case w.status < http.StatusOK,
+		w.status == http.StatusNoContent,
+		w.status == http.StatusNotModified,
+		w.status == http.StatusPartialContent:
+		return false
+	}
+
+	header := w.Header()
+	if header.Get(zen.HeaderContentEncoding) != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+	contentType := header.Get(zen.HeaderContentType)
+	if contentType == "" {
+		// net/http would sniff it from uncompressed body
+		contentType = http.DetectContentType(w.buf)
+		header.Set(zen.HeaderContentType, contentType)
+	}
+	return matchContentType(contentType, w.contentTypes)
+}
+
+func (w *writer) Flush() {
+	if !w.decided {
+		w.decide()
+	}
+	if w.encoder != nil {
+		w.encoder.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.decided = true
+	return w.ResponseWriter.Hijack()
+}
+
+func (w *writer) Status() int {
+	if w.decided {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+// Size return the number of uncompressed body bytes written
+func (w *writer) Size() int {
+	return w.size
+}
+
+func (w *writer) Written() bool {
+	return w.Status() != 0
+}
+
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close write the rest of response and put the encoder back to pool
+func (w *writer) close() {
+	if !w.decided {
+		if w.status == 0 && len(w.buf) == 0 {
+			// nothing written by handler
+			w.decided = true
+			return
+		}
+		w.decide()
+	}
+	if w.encoder == nil {
+		return
+	}
+
+	w.encoder.Close()
+	// do not keep the response writer alive in the pool
+	switch encoder := w.encoder.(type) {
+	case *gzip.Writer:
+		encoder.Reset(io.Discard)
+		w.gzipPool.Put(encoder)
+	case *flate.Writer:
+		encoder.Reset(io.Discard)
+		w.flatePool.Put(encoder)
+	}
+	w.encoder = nil
+}
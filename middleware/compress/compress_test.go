@@ -0,0 +1,157 @@
+package compress
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/philchia/zen"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"gzip", EncodingGzip},
+		{"deflate, gzip", EncodingGzip},
+		{"gzip;q=0.5, deflate", EncodingDeflate},
+		{"gzip;q=0, *", EncodingDeflate},
+		{"*;q=0", ""},
+		{"br", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := negotiate(tt.header); got != tt.want {
+				t.Errorf("negotiate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	large := strings.Repeat("zen ", 512)
+
+	server := zen.New()
+	server.AddInterceptor(New(SetMinLength(100)))
+	server.Get("/large", func(ctx zen.Context) {
+		ctx.WriteHeader(zen.HeaderContentType, zen.MIMETextPlainCharsetUTF8)
+		ctx.WriteString(large)
+	})
+	server.Get("/small", func(ctx zen.Context) {
+		ctx.WriteString("zen")
+	})
+	server.Get("/image", func(ctx zen.Context) {
+		ctx.WriteData("image/png", []byte(large))
+	})
+	server.Get("/encoded", func(ctx zen.Context) {
+		ctx.WriteHeader(zen.HeaderContentEncoding, "br")
+		ctx.WriteString(large)
+	})
+	server.Get("/file", func(ctx zen.Context) {
+		ctx.ServeContent("file.txt", time.Time{}, strings.NewReader(large))
+	})
+	server.Get("/stream", func(ctx zen.Context) {
+		ctx.WriteHeader(zen.HeaderContentType, zen.MIMETextPlain)
+		for i := 0; i < 3; i++ {
+			ctx.WriteString(large)
+			ctx.Rw.(zen.ResponseWriter).Flush()
+		}
+	})
+
+	tests := []struct {
+		name     string
+		path     string
+		encoding string
+		rng      string
+		want     string
+		body     string
+	}{
+		{"gzip", "/large", "gzip", "", EncodingGzip, large},
+		{"deflate", "/large", "deflate", "", EncodingDeflate, large},
+		{"identity", "/large", "", "", "", large},
+		{"small", "/small", "gzip", "", "", "zen"},
+		{"not allowed type", "/image", "gzip", "", "", large},
+		{"already encoded", "/encoded", "gzip", "", "br", large},
+		{"file", "/file", "gzip", "", EncodingGzip, large},
+		{"range", "/file", "gzip", "bytes=0-3", "", "zen "},
+		{"stream", "/stream", "gzip", "", EncodingGzip, large + large + large},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req.Header.Set(zen.HeaderAcceptEncoding, tt.encoding)
+			if tt.rng != "" {
+				req.Header.Set("Range", tt.rng)
+			}
+			rw := httptest.NewRecorder()
+			server.ServeHTTP(rw, req)
+
+			if encoding := rw.Header().Get(zen.HeaderContentEncoding); encoding != tt.want {
+				t.Fatalf("New() Content-Encoding = %q, want %q", encoding, tt.want)
+			}
+			if vary := rw.Header().Get(zen.HeaderVary); vary != zen.HeaderAcceptEncoding {
+				t.Errorf("New() Vary = %q, want %q", vary, zen.HeaderAcceptEncoding)
+			}
+
+			var body io.Reader = rw.Body
+			switch tt.want {
+			case EncodingGzip:
+				if cl := rw.Header().Get(zen.HeaderContentLength); cl != "" {
+					t.Errorf("New() Content-Length = %q, want empty", cl)
+				}
+				gr, err := gzip.NewReader(body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = gr
+			case EncodingDeflate:
+				body = flate.NewReader(body)
+			}
+			data, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.body {
+				t.Errorf("New() body length = %d, want %d", len(data), len(tt.body))
+			}
+		})
+	}
+}
+
+func TestNew_etag(t *testing.T) {
+	server := zen.New()
+	server.AddInterceptor(New(SetMinLength(1)))
+	server.Get("/strong", func(ctx zen.Context) {
+		ctx.SetETag("v1", false)
+		ctx.WriteString("zen")
+	})
+	server.Get("/weak", func(ctx zen.Context) {
+		ctx.SetETag("v1", true)
+		ctx.WriteString("zen")
+	})
+
+	tests := []struct {
+		path     string
+		encoding string
+		want     string
+	}{
+		{"/strong", "gzip", `W/"v1"`},
+		{"/strong", "", `"v1"`},
+		{"/weak", "deflate", `W/"v1"`},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		req.Header.Set(zen.HeaderAcceptEncoding, tt.encoding)
+		rw := httptest.NewRecorder()
+		server.ServeHTTP(rw, req)
+		if etag := rw.Header().Get(zen.HeaderETag); etag != tt.want {
+			t.Errorf("New() %s with %q ETag = %s, want %s", tt.path, tt.encoding, etag, tt.want)
+		}
+	}
+}
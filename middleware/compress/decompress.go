@@ -0,0 +1,164 @@
+package compress
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/philchia/zen"
+)
+
+// DefaultMaxDecompressedSize is the max size of decoded request bodies when Decompress is given 0
+const DefaultMaxDecompressedSize = 10 << 20
+
+// decodedBody close the decoders and the original body
+type decodedBody struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (b *decodedBody) Close() error {
+	var err error
+	for i := len(b.closers) - 1; i >= 0; i-- {
+		if cerr := b.closers[i].Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Decompress return a zen.Middleware which decode request bodies encoded with
+// gzip or deflate in Content-Encoding, so binders and WrapF handlers read the
+// decoded body. At most maxSize bytes are decoded, reading more fails with
+// *http.MaxBytesError which is answered with 413 Request Entity Too Large,
+// 0 means DefaultMaxDecompressedSize and a negative maxSize means no limit.
+// Requests with other encodings are answered with 415 Unsupported Media Type,
+// and malformed or corrupted bodies fail with a 400 Bad Request *zen.HTTPError.
+func Decompress(maxSize int64) zen.Middleware {
+	if maxSize == 0 {
+		maxSize = DefaultMaxDecompressedSize
+	}
+	return func(h zen.HandlerFunc) zen.HandlerFunc {
+		return func(ctx zen.Context) {
+			header := ctx.Req.Header.Get(zen.HeaderContentEncoding)
+			if header == "" || ctx.Req.Body == nil || ctx.Req.Body == http.NoBody {
+				h(ctx)
+				return
+			}
+
+			body, err := decode(ctx.Req.Body, header)
+			if err != nil {
+				ctx.Error(err)
+				return
+			}
+			body.Reader = corruptionReader{body.Reader}
+			if maxSize > 0 {
+				body.Reader = http.MaxBytesReader(nil, io.NopCloser(body.Reader), maxSize)
+			}
+
+			ctx.Req.Body = body
+			ctx.Req.GetBody = nil
+			ctx.Req.ContentLength = -1
+			ctx.Req.Header.Del(zen.HeaderContentEncoding)
+			ctx.Req.Header.Del(zen.HeaderContentLength)
+			h(ctx)
+		}
+	}
+}
+
+// decode wrap body with decoders of codings in header, which are applied in the listed order
+func decode(body io.ReadCloser, header string) (*decodedBody, error) {
+	codings := strings.Split(header, ",")
+	decoded := &decodedBody{Reader: body, closers: []io.Closer{body}}
+	for i := len(codings) - 1; i >= 0; i-- {
+		coding := strings.ToLower(strings.TrimSpace(codings[i]))
+		switch coding {
+		case "identity", "":
+		case EncodingGzip, "x-gzip":
+			gr, err := gzip.NewReader(decoded.Reader)
+			if err != nil {
+				return nil, zen.NewHTTPError(zen.StatusBadRequest, "malformed gzip body").WithCause(err)
+			}
+			decoded.Reader = gr
+			decoded.closers = append(decoded.closers, gr)
+		case EncodingDeflate:
+			// deflate is zlib format, raw deflate sent by some clients is also accepted
+			r := newDeflateReader(decoded.Reader)
+			decoded.Reader = r
+			decoded.closers = append(decoded.closers, r)
+		default:
+			return nil, zen.NewHTTPError(zen.StatusUnsupportedMediaType, "unsupported content encoding "+coding)
+		}
+	}
+	return decoded, nil
+}
+
+// corruptionReader turn errors of decoders on corrupted data, which may be found
+// in the middle of the body, into a 400 Bad Request HTTPError
+type corruptionReader struct {
+	r io.Reader
+}
+
+func (c corruptionReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if err != nil && isCorruption(err) {
+		err = zen.NewHTTPError(zen.StatusBadRequest, "malformed compressed body").WithCause(err)
+	}
+	return n, err
+}
+
+// isCorruption report whether err is caused by corrupted or truncated compressed data
+func isCorruption(err error) bool {
+	var corrupt flate.CorruptInputError
+	switch {
+	case errors.As(err, &corrupt):
+		return true
+	case errors.Is(err, gzip.ErrChecksum), errors.Is(err, gzip.ErrHeader),
+		errors.Is(err, zlib.ErrChecksum), errors.Is(err, zlib.ErrHeader), errors.Is(err, zlib.ErrDictionary):
+		return true
+	}
+	return err == io.ErrUnexpectedEOF
+}
+
+// deflateReader decode zlib format, or raw deflate if the zlib header is invalid
+type deflateReader struct {
+	src io.Reader
+	r   io.ReadCloser
+}
+
+func newDeflateReader(src io.Reader) *deflateReader {
+	return &deflateReader{src: src}
+}
+
+func (d *deflateReader) Read(p []byte) (int, error) {
+	if d.r == nil {
+		var header [2]byte
+		n, err := io.ReadFull(d.src, header[:])
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return 0, err
+		}
+		src := io.MultiReader(strings.NewReader(string(header[:n])), d.src)
+		// zlib header: CM is 8 and the header is a multiple of 31
+		if n == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			zr, err := zlib.NewReader(src)
+			if err != nil {
+				return 0, zen.NewHTTPError(zen.StatusBadRequest, "malformed deflate body").WithCause(err)
+			}
+			d.r = zr
+		} else {
+			d.r = flate.NewReader(src)
+		}
+	}
+	return d.r.Read(p)
+}
+
+func (d *deflateReader) Close() error {
+	if d.r == nil {
+		return nil
+	}
+	return d.r.Close()
+}
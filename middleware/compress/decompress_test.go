@@ -0,0 +1,129 @@
+package compress
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/philchia/zen"
+)
+
+func encode(t *testing.T, encoding, s string) []byte {
+	var (
+		buf bytes.Buffer
+		w   io.WriteCloser
+	)
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zlib":
+		w = zlib.NewWriter(&buf)
+	case "raw":
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	}
+	if _, err := io.WriteString(w, s); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	return buf.Bytes()
+}
+
+func TestDecompress(t *testing.T) {
+	server := zen.New()
+	server.AddInterceptor(Decompress(1024))
+	server.Post("/bind", func(ctx zen.Context) {
+		var input struct {
+			Name string `json:"name"`
+		}
+		if err := ctx.BindJSON(&input); err != nil {
+			ctx.Error(err)
+			return
+		}
+		ctx.WriteString(input.Name)
+	})
+	server.Post("/read", func(ctx zen.Context) {
+		data, err := io.ReadAll(ctx.Req.Body)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+		ctx.Rw.Write(data)
+	})
+	server.Post("/std", zen.WrapF(func(rw http.ResponseWriter, req *http.Request) {
+		io.Copy(rw, req.Body)
+	}))
+
+	bomb := `{"name":"` + strings.Repeat("z", 4096) + `"}`
+	large := `{"name":"` + strings.Repeat("zen", 100) + `"}`
+	gz := encode(t, "gzip", large)
+	truncated := gz[:len(gz)/2]
+	corrupted := append([]byte(nil), gz...)
+	corrupted[12] ^= 0xff
+	badChecksum := append([]byte(nil), gz...)
+	badChecksum[len(badChecksum)-8] ^= 0xff
+	tests := []struct {
+		name     string
+		path     string
+		encoding string
+		body     []byte
+		code     int
+		want     string
+	}{
+		{"gzip", "/bind", "gzip", encode(t, "gzip", `{"name":"zen"}`), zen.StatusOK, "zen"},
+		{"zlib deflate", "/bind", "deflate", encode(t, "zlib", `{"name":"zen"}`), zen.StatusOK, "zen"},
+		{"raw deflate", "/bind", "deflate", encode(t, "raw", `{"name":"zen"}`), zen.StatusOK, "zen"},
+		{"identity", "/bind", "identity", []byte(`{"name":"zen"}`), zen.StatusOK, "zen"},
+		{"plain", "/bind", "", []byte(`{"name":"zen"}`), zen.StatusOK, "zen"},
+		{"wrapf", "/std", "gzip", encode(t, "gzip", "raw body"), zen.StatusOK, "raw body"},
+		{"bomb", "/bind", "gzip", encode(t, "gzip", bomb), zen.StatusRequestEntityTooLarge, ""},
+		{"unsupported", "/bind", "br", []byte("x"), zen.StatusUnsupportedMediaType, ""},
+		{"malformed", "/bind", "gzip", []byte("not gzip"), zen.StatusBadRequest, ""},
+		{"truncated", "/bind", "gzip", truncated, zen.StatusBadRequest, ""},
+		{"corrupted", "/bind", "gzip", corrupted, zen.StatusBadRequest, ""},
+		// reserved block type
+		{"corrupted deflate", "/bind", "deflate", []byte{0x07, 0x00}, zen.StatusBadRequest, ""},
+		{"checksum", "/read", "gzip", badChecksum, zen.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.path, bytes.NewReader(tt.body))
+			req.Header.Set(zen.HeaderContentType, zen.MIMEApplicationJSON)
+			if tt.encoding != "" {
+				req.Header.Set(zen.HeaderContentEncoding, tt.encoding)
+			}
+			rw := httptest.NewRecorder()
+			server.ServeHTTP(rw, req)
+			if rw.Code != tt.code {
+				t.Errorf("Decompress() code = %d, want %d, body %s", rw.Code, tt.code, rw.Body.String())
+			}
+			if tt.want != "" && rw.Body.String() != tt.want {
+				t.Errorf("Decompress() body = %q, want %q", rw.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDecompress_defaultLimit(t *testing.T) {
+	server := zen.New()
+	server.AddInterceptor(Decompress(0))
+	server.Post("/read", func(ctx zen.Context) {
+		if _, err := io.Copy(io.Discard, ctx.Req.Body); err != nil {
+			ctx.Error(err)
+		}
+	})
+
+	body := encode(t, "gzip", strings.Repeat("z", DefaultMaxDecompressedSize+1))
+	req := httptest.NewRequest("POST", "/read", bytes.NewReader(body))
+	req.Header.Set(zen.HeaderContentEncoding, "gzip")
+	rw := httptest.NewRecorder()
+	server.ServeHTTP(rw, req)
+	if rw.Code != zen.StatusRequestEntityTooLarge {
+		t.Errorf("Decompress(0) code = %d, want %d", rw.Code, zen.StatusRequestEntityTooLarge)
+	}
+}
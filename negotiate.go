@@ -0,0 +1,90 @@
+package zen
+
+import (
+	"strconv"
+	"strings"
+)
+
+// acceptSpec is a single media range of Accept header
+type acceptSpec struct {
+	value string
+	q     float64
+}
+
+// parseAccept parse an Accept style header into specs, entries with invalid q are ignored
+func parseAccept(header string) []acceptSpec {
+	var specs []acceptSpec
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		value := strings.ToLower(strings.TrimSpace(fields[0]))
+		if value == "" {
+			continue
+		}
+
+		spec := acceptSpec{value: value, q: 1}
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			q, err := strconv.ParseFloat(param[2:], 64)
+			if err != nil || q < 0 || q > 1 {
+				q = -1
+			}
+			spec.q = q
+		}
+		if spec.q >= 0 {
+			specs = append(specs, spec)
+		}
+	}
+	return specs
+}
+
+// specificity return how specific spec matches value, -1 if it does not match,
+// exact matches are more specific than type/* which is more specific than */*
+func (spec acceptSpec) specificity(value string) int {
+	switch {
+	case spec.value == value:
+		return 2
+	case strings.HasSuffix(spec.value, "/*") && strings.HasPrefix(value, spec.value[:len(spec.value)-1]):
+		return 1
+	case spec.value == "*/*" || spec.value == "*":
+		return 0
+	}
+	return -1
+}
+
+// negotiate return the offer with the highest q value in header, the q value of
+// an offer is taken from its most specific matching range, offers with q=0 are not acceptable
+func negotiate(header string, offers []string) string {
+	specs := parseAccept(header)
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		lower := strings.ToLower(offer)
+		q, specific := 0.0, -1
+		for _, spec := range specs {
+			if s := spec.specificity(lower); s > specific {
+				q, specific = spec.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// Negotiate return the offered media type which best matches request's Accept header,
+// the first offer is returned if the request has no Accept header, and an empty
+// string is returned if none of the offers is acceptable
+func (ctx *Context) Negotiate(offers ...string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	accept := ctx.Req.Header.Get(HeaderAccept)
+	if accept == "" {
+		return offers[0]
+	}
+	return negotiate(accept, offers)
+}
@@ -2,6 +2,8 @@ package zen
 
 import (
 	"time"
+
+	log "github.com/sirupsen/logrus"
 )
 
 // Option use to customize Server
@@ -64,3 +66,30 @@ func SetHandleOPTIONS(b bool) Option {
 		s.HandleOPTIONS = b
 	}
 }
+
+// SetMaxBodyBytes return Option for set MaxBodyBytes
+func SetMaxBodyBytes(n int64) Option {
+	return func(s *Server) {
+		s.MaxBodyBytes = n
+	}
+}
+
+// SetDebug return Option for enable debug mode, it only take effect if environment
+// variable ZEN_DEBUG is "1" or "true", so it can not be enabled in production by accident.
+// In debug mode the server print route table and configuration warnings when it starts,
+// and answer panics and 5xx responses with a html page of stack trace and request details.
+func SetDebug(b bool) Option {
+	return func(s *Server) {
+		s.debug = b && debugAllowed()
+		if b && !s.debug {
+			log.Warnf("zen: debug mode is ignored as %s is not set", DebugEnv)
+		}
+	}
+}
+
+// SetStrictJSON return Option for set StrictJSON
+func SetStrictJSON(b bool) Option {
+	return func(s *Server) {
+		s.StrictJSON = b
+	}
+}
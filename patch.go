@@ -0,0 +1,470 @@
+package zen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"mime"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// PatchError is returned by BindPatch when a patch can not be applied
+type PatchError struct {
+	// Code is the http status code which should be answered,
+	// 409 for failed test operations, 415 for unsupported patch formats
+	// and 422 for invalid operations or patch results
+	Code int
+	// Op is the failed operation of a json patch
+	Op string
+	// Path is the json pointer of the failed operation
+	Path string
+	Err  error
+}
+
+func (e *PatchError) Error() string {
+	if e.Op != "" {
+		return fmt.Sprintf("zen: patch %s %q failed: %v", e.Op, e.Path, e.Err)
+	}
+	return fmt.Sprintf("zen: patch failed: %v", e.Err)
+}
+
+// Unwrap return the underlying error
+func (e *PatchError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode return the http status code which should be answered for this error
+func (e *PatchError) StatusCode() int {
+	return e.Code
+}
+
+// patchOperation is a single RFC 6902 operation
+type patchOperation struct {
+	Op    string           `json:"op"`
+	Path  *string          `json:"path"`
+	From  *string          `json:"from"`
+	Value *json.RawMessage `json:"value"`
+}
+
+// BindPatch apply request's patch body to target and validate the result with valid tags,
+// target must be a pointer to the current value of the resource. Both json patch
+// (application/json-patch+json, RFC 6902) and json merge patch
+// (application/merge-patch+json, RFC 7396) are supported.
+// Fields of target which are not serialized to json are reset to zero value.
+// Malformed bodies are returned as *BindError, patches which can not be applied
+// are returned as *PatchError.
+func (ctx *Context) BindPatch(target interface{}) error {
+	mediaType, _, _ := mime.ParseMediaType(ctx.Req.Header.Get(HeaderContentType))
+	if mediaType != MIMEApplicationJSONPatch && mediaType != MIMEApplicationMergePatch {
+		return &PatchError{
+			Code: StatusUnsupportedMediaType,
+			Err:  fmt.Errorf("unsupported patch content type %q", mediaType),
+		}
+	}
+
+	ctx.rewindBody()
+	body, err := ioutil.ReadAll(ctx.Req.Body)
+	if err != nil {
+		return newBindError(err)
+	}
+
+	doc, err := jsonValue(target)
+	if err != nil {
+		return err
+	}
+
+	if mediaType == MIMEApplicationMergePatch {
+		var patch interface{}
+		if err := decodeJSON(bytes.NewReader(body), &patch, false); err != nil {
+			return err
+		}
+		doc = mergePatch(doc, patch)
+	} else {
+		var ops []patchOperation
+		if err := decodeJSON(bytes.NewReader(body), &ops, false); err != nil {
+			return err
+		}
+		if doc, err = applyPatch(doc, ops); err != nil {
+			return err
+		}
+	}
+
+	// target is only changed once the result is valid
+	result, err := decodeJSONValue(doc, target)
+	if err != nil {
+		return &PatchError{Code: StatusUnprocessableEntity, Err: err}
+	}
+	if err := validateStruct(result.Interface()); err != nil {
+		return &PatchError{Code: StatusUnprocessableEntity, Err: err}
+	}
+	reflect.ValueOf(target).Elem().Set(result.Elem())
+	return nil
+}
+
+// jsonValue convert v into generic json value
+func jsonValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err = decoder.Decode(&ret)
+	return ret, err
+}
+
+// decodeJSONValue decode generic json value into a copy of target's value,
+// fields which are not encoded into json are kept, and return a pointer to it
+func decodeJSONValue(doc, target interface{}) (reflect.Value, error) {
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Ptr || targetValue.IsNil() {
+		return reflect.Value{}, errors.New("patch target must be a non-nil pointer")
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	fresh := reflect.New(targetValue.Elem().Type())
+	fresh.Elem().Set(targetValue.Elem())
+	clearJSONFields(fresh.Elem())
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(fresh.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	return fresh, nil
+}
+
+// clearJSONFields zero the fields of struct v which are encoded into json, so
+// the decoded document replace them, other fields like unexported ones or ones
+// tagged with json:"-" are kept. Pointers to struct are copied before being
+// cleared so the original value is not changed.
+func clearJSONFields(v reflect.Value) {
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if (field.PkgPath != "" && !field.Anonymous) || field.Tag.Get("json") == "-" {
+			continue
+		}
+
+		f := v.Field(i)
+		switch {
+		case !f.CanSet():
+			// unexported embedded struct, exported fields of it are promoted
+			if f.Kind() == reflect.Struct {
+				clearJSONFields(f)
+			}
+		case f.Kind() == reflect.Struct:
+			clearJSONFields(f)
+		case f.Kind() == reflect.Ptr && !f.IsNil() && f.Elem().Kind() == reflect.Struct:
+			p := reflect.New(f.Elem().Type())
+			p.Elem().Set(f.Elem())
+			clearJSONFields(p.Elem())
+			f.Set(p)
+		default:
+			f.Set(reflect.Zero(f.Type()))
+		}
+	}
+}
+
+// mergePatch apply a RFC 7396 merge patch to target
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = mergePatch(t[k], v)
+	}
+	return t
+}
+
+// applyPatch apply RFC 6902 operations to doc in order
+func applyPatch(doc interface{}, ops []patchOperation) (interface{}, error) {
+	for _, op := range ops {
+		if op.Path == nil {
+			return nil, &PatchError{Code: StatusUnprocessableEntity, Op: op.Op, Err: errors.New("missing path")}
+		}
+
+		var err error
+		doc, err = applyOperation(doc, op)
+		if err != nil {
+			code := StatusUnprocessableEntity
+			if op.Op == "test" {
+				code = StatusConflict
+			}
+			return nil, &PatchError{Code: code, Op: op.Op, Path: *op.Path, Err: err}
+		}
+	}
+	return doc, nil
+}
+
+func applyOperation(doc interface{}, op patchOperation) (interface{}, error) {
+	path, err := parsePointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, errors.New("missing value")
+		}
+		decoder := json.NewDecoder(bytes.NewReader(*op.Value))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+	case "move", "copy":
+		if op.From == nil {
+			return nil, errors.New("missing from")
+		}
+		from, err := parsePointer(*op.From)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" && strings.HasPrefix(*op.Path+"/", *op.From+"/") && *op.Path != *op.From {
+			return nil, errors.New("can not move a value into one of its children")
+		}
+		if value, err = pointerGet(doc, from); err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if doc, err = pointerRemove(doc, from); err != nil {
+				return nil, err
+			}
+		} else if value, err = jsonValue(value); err != nil {
+			return nil, err
+		}
+	}
+
+	switch op.Op {
+	case "add", "move", "copy":
+		return pointerAdd(doc, path, value)
+	case "remove":
+		return pointerRemove(doc, path)
+	case "replace":
+		if _, err := pointerGet(doc, path); err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return value, nil
+		}
+		if doc, err = pointerRemove(doc, path); err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, path, value)
+	case "test":
+		current, err := pointerGet(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonEqual(current, value) {
+			return nil, errors.New("test value mismatch")
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("unknown operation %q", op.Op)
+}
+
+// parsePointer parse a RFC 6901 json pointer into reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("invalid json pointer %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(tokens[i], "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// arrayIndex parse token as index of an array with length n,
+// "-" and n are only allowed when appending
+func arrayIndex(token string, n int, appending bool) (int, error) {
+	if appending && token == "-" {
+		return n, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (token != "0" && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if i > n || (i == n && !appending) {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+	return i, nil
+}
+
+func pointerGet(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			child, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("member %q not found", token)
+			}
+			doc = child
+		case []interface{}:
+			i, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[i]
+		default:
+			return nil, fmt.Errorf("can not reference %q in a scalar value", token)
+		}
+	}
+	return doc, nil
+}
+
+// pointerUpdate walk to the container of path's last token and replace it with update's result
+func pointerUpdate(doc interface{}, path []string, update func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return update(doc, path[0])
+	}
+
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		child, ok := node[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("member %q not found", path[0])
+		}
+		child, err := pointerUpdate(child, path[1:], update)
+		if err != nil {
+			return nil, err
+		}
+		node[path[0]] = child
+		return node, nil
+	case []interface{}:
+		i, err := arrayIndex(path[0], len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		child, err := pointerUpdate(node[i], path[1:], update)
+		if err != nil {
+			return nil, err
+		}
+		node[i] = child
+		return node, nil
+	}
+	return nil, fmt.Errorf("can not reference %q in a scalar value", path[0])
+}
+
+func pointerAdd(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return pointerUpdate(doc, path, func(container interface{}, token string) (interface{}, error) {
+		switch node := container.(type) {
+		case map[string]interface{}:
+			node[token] = value
+			return node, nil
+		case []interface{}:
+			i, err := arrayIndex(token, len(node), true)
+			if err != nil {
+				return nil, err
+			}
+			node = append(node, nil)
+			copy(node[i+1:], node[i:])
+			node[i] = value
+			return node, nil
+		}
+		return nil, fmt.Errorf("can not add %q to a scalar value", token)
+	})
+}
+
+func pointerRemove(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, errors.New("can not remove the whole document")
+	}
+
+	return pointerUpdate(doc, path, func(container interface{}, token string) (interface{}, error) {
+		switch node := container.(type) {
+		case map[string]interface{}:
+			if _, ok := node[token]; !ok {
+				return nil, fmt.Errorf("member %q not found", token)
+			}
+			delete(node, token)
+			return node, nil
+		case []interface{}:
+			i, err := arrayIndex(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			return append(node[:i], node[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("can not remove %q from a scalar value", token)
+	})
+}
+
+// jsonEqual compare two generic json values, numbers are compared by value
+func jsonEqual(a, b interface{}) bool {
+	switch x := a.(type) {
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		return numberEqual(x, y)
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			if w, ok := y[k]; !ok || !jsonEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !jsonEqual(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+// numberEqual report whether x and y are the same number, without the rounding
+// of float64 which make large integers equal
+func numberEqual(x, y json.Number) bool {
+	// enough bits to keep distinct decimals distinct
+	prec := uint(len(x)+len(y))*4 + 64
+	fx, _, errx := big.ParseFloat(string(x), 10, prec, big.ToNearestEven)
+	fy, _, erry := big.ParseFloat(string(y), 10, prec, big.ToNearestEven)
+	return errx == nil && erry == nil && fx.Cmp(fy) == 0
+}
@@ -0,0 +1,102 @@
+package zen
+
+import (
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type patchTarget struct {
+	Name  string            `json:"name" valid:"^[a-z]+$" msg:"illegal name"`
+	Age   int               `json:"age"`
+	Tags  []string          `json:"tags"`
+	Attrs map[string]string `json:"attrs,omitempty"`
+	ID    int64             `json:"id"`
+	Token string            `json:"-"`
+	token string
+}
+
+func newPatchTarget() patchTarget {
+	return patchTarget{Name: "zen", Age: 1, Tags: []string{"a", "b"}, ID: 9007199254740993, Token: "keep", token: "keep"}
+}
+
+func newPatchContext(contentType, body string) Context {
+	req := httptest.NewRequest("PATCH", "/patch", strings.NewReader(body))
+	req.Header.Set(HeaderContentType, contentType)
+	return getContext(httptest.NewRecorder(), req)
+}
+
+func TestContext_BindPatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        patchTarget
+		code        int
+	}{
+		{
+			"json patch",
+			MIMEApplicationJSONPatch,
+			`[
+				{"op":"test","path":"/name","value":"zen"},
+				{"op":"replace","path":"/age","value":2},
+				{"op":"add","path":"/tags/-","value":"c"},
+				{"op":"add","path":"/tags/0","value":"z"},
+				{"op":"remove","path":"/tags/1"},
+				{"op":"copy","from":"/name","path":"/attrs"},
+				{"op":"replace","path":"/attrs","value":{"a~b/c":"d"}},
+				{"op":"move","from":"/attrs/a~0b~1c","path":"/attrs/e"}
+			]`,
+			patchTarget{Name: "zen", Age: 2, Tags: []string{"z", "b", "c"}, Attrs: map[string]string{"e": "d"}, ID: 9007199254740993, Token: "keep", token: "keep"},
+			0,
+		},
+		{
+			"merge patch",
+			MIMEApplicationMergePatch,
+			`{"age":3,"tags":null,"attrs":{"k":"v"}}`,
+			patchTarget{Name: "zen", Age: 3, Attrs: map[string]string{"k": "v"}, ID: 9007199254740993, Token: "keep", token: "keep"},
+			0,
+		},
+		{"test failed", MIMEApplicationJSONPatch, `[{"op":"test","path":"/name","value":"go"}]`, patchTarget{}, StatusConflict},
+		{"test large integer", MIMEApplicationJSONPatch, `[{"op":"test","path":"/id","value":9007199254740992}]`, patchTarget{}, StatusConflict},
+		{"missing path", MIMEApplicationJSONPatch, `[{"op":"remove","path":"/nope"}]`, patchTarget{}, StatusUnprocessableEntity},
+		{"unknown op", MIMEApplicationJSONPatch, `[{"op":"nope","path":"/name"}]`, patchTarget{}, StatusUnprocessableEntity},
+		{"unknown field", MIMEApplicationMergePatch, `{"nope":1}`, patchTarget{}, StatusUnprocessableEntity},
+		{"invalid", MIMEApplicationMergePatch, `{"name":"ZEN"}`, patchTarget{}, StatusUnprocessableEntity},
+		{"unsupported", MIMEApplicationJSON, `{}`, patchTarget{}, StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := newPatchTarget()
+			ctx := newPatchContext(tt.contentType, tt.body)
+			err := ctx.BindPatch(&target)
+
+			if tt.code != 0 {
+				var patchErr *PatchError
+				if !errors.As(err, &patchErr) || patchErr.StatusCode() != tt.code {
+					t.Errorf("Context.BindPatch() error = %v, want code %d", err, tt.code)
+				}
+				if original := newPatchTarget(); !reflect.DeepEqual(target, original) {
+					t.Errorf("Context.BindPatch() changed target to %+v on error", target)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Context.BindPatch() error = %v", err)
+			}
+			if !reflect.DeepEqual(target, tt.want) {
+				t.Errorf("Context.BindPatch() = %+v, want %+v", target, tt.want)
+			}
+		})
+	}
+}
+
+func TestContext_BindPatchMalformed(t *testing.T) {
+	var target patchTarget
+	ctx := newPatchContext(MIMEApplicationJSONPatch, `[{"op":`)
+	if _, ok := ctx.BindPatch(&target).(*BindError); !ok {
+		t.Error("Context.BindPatch() want *BindError for malformed body")
+	}
+}
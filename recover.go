@@ -0,0 +1,100 @@
+package zen
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// Reporter report recovered panics to an external crash reporting service
+type Reporter interface {
+	Report(ctx Context, err *PanicError)
+}
+
+// ReporterFunc adapt a function into Reporter
+type ReporterFunc func(ctx Context, err *PanicError)
+
+// Report call f(ctx, err)
+func (f ReporterFunc) Report(ctx Context, err *PanicError) {
+	f(ctx, err)
+}
+
+// PanicError is a panic recovered from handler
+type PanicError struct {
+	// Value is the value passed to panic
+	Value interface{}
+	// Stack is the stack trace of the panicking goroutine
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("zen: panic: %v", e.Value)
+}
+
+// Unwrap return Value if it is an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// StatusCode return 500 Internal Server Error
+func (e *PanicError) StatusCode() int {
+	return StatusInternalServerError
+}
+
+// SetReporter set the Reporter of panics recovered by server
+func (s *Server) SetReporter(reporter Reporter) {
+	s.reporter = reporter
+}
+
+// Recover return a Middleware which recover panics of handler with reporter.
+// Server always recover panics, Recover is needed for handlers served with UnWrapF,
+// or to report panics of some routes with another reporter.
+func Recover(reporter Reporter) Middleware {
+	return func(h HandlerFunc) HandlerFunc {
+		return func(ctx Context) {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					handlePanic(ctx, recovered, reporter)
+				}
+			}()
+			h(ctx)
+		}
+	}
+}
+
+// recoverPanic must be deferred, it recover panics with server's reporter
+func (s *Server) recoverPanic(ctx Context) {
+	if recovered := recover(); recovered != nil {
+		// fields set by interceptors and handler are not in ctx
+		ctx.withRequestFields()
+		handlePanic(ctx, recovered, s.reporter)
+	}
+}
+
+// handlePanic log recovered panic with stack and context fields, report it with reporter,
+// and answer 500 with Context.Error if the header is not sent yet.
+// http.ErrAbortHandler is panicked again so net/http abort the response silently,
+// the response is also aborted if the header is already sent as it can not be completed.
+func handlePanic(ctx Context, recovered interface{}, reporter Reporter) {
+	if recovered == http.ErrAbortHandler {
+		panic(recovered)
+	}
+
+	err := &PanicError{Value: recovered, Stack: debug.Stack()}
+	ctx.LogError(err, "\n"+string(err.Stack))
+	if reporter != nil {
+		reporter.Report(ctx, err)
+	}
+
+	w, ok := trackingWriter(ctx.Rw)
+	if ok && w.committed {
+		panic(http.ErrAbortHandler)
+	}
+	if ok {
+		// drop the status written before panic, it is not sent yet
+		w.status = 0
+	}
+	discardBuffers(ctx.Rw)
+	ctx.Error(err)
+}
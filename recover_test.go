@@ -0,0 +1,165 @@
+package zen
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestServer_recoverPanic(t *testing.T) {
+	var reported *PanicError
+	server := New()
+	server.SetReporter(ReporterFunc(func(ctx Context, err *PanicError) {
+		reported = err
+	}))
+	server.Get("/panic", func(ctx Context) {
+		panic("boom")
+	})
+	server.Get("/status", func(ctx Context) {
+		ctx.WriteStatus(StatusCreated)
+		panic(errors.New("boom"))
+	})
+	server.Get("/written", func(ctx Context) {
+		ctx.WriteString("partial")
+		panic("boom")
+	})
+	server.Get("/abort", func(ctx Context) {
+		panic(http.ErrAbortHandler)
+	})
+
+	var logs bytes.Buffer
+	SetLogOutput(&logs)
+	defer SetLogOutput(os.Stderr)
+
+	tests := []struct {
+		path   string
+		code   int
+		abort  bool
+		report bool
+	}{
+		{"/panic", StatusInternalServerError, false, true},
+		{"/status", StatusInternalServerError, false, true},
+		{"/written", StatusOK, true, true},
+		{"/abort", StatusOK, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			reported = nil
+			rw := httptest.NewRecorder()
+			func() {
+				defer func() {
+					recovered := recover()
+					if abort := recovered == http.ErrAbortHandler; abort != tt.abort {
+						t.Errorf("Server.ServeHTTP() panic = %v, want abort %v", recovered, tt.abort)
+					}
+				}()
+				server.ServeHTTP(rw, httptest.NewRequest("GET", tt.path, nil))
+			}()
+
+			if rw.Code != tt.code {
+				t.Errorf("Server.ServeHTTP() code = %d, want %d", rw.Code, tt.code)
+			}
+			if (reported != nil) != tt.report {
+				t.Fatalf("Reporter.Report() called = %v, want %v", reported != nil, tt.report)
+			}
+			if reported != nil && (reported.Value == nil || len(reported.Stack) == 0) {
+				t.Errorf("Reporter.Report() err = %#v, want value and stack", reported)
+			}
+		})
+	}
+
+	if log := logs.String(); strings.Count(log, "zen: panic: boom") != 3 || !strings.Contains(log, "recover_test.go") {
+		t.Errorf("Server.ServeHTTP() log = %q, want every panic logged once with stack", log)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	var logs bytes.Buffer
+	SetLogOutput(&logs)
+	defer SetLogOutput(os.Stderr)
+
+	var reported bool
+	setField := func(h HandlerFunc) HandlerFunc {
+		return func(ctx Context) {
+			ctx.SetField("uid", "42")
+			h(ctx)
+		}
+	}
+	recovery := Recover(ReporterFunc(func(ctx Context, err *PanicError) {
+		reported = true
+	}))
+	handler := UnWrapF(Middlewares{recovery, setField}.Wrap(func(ctx Context) {
+		panic("boom")
+	}))
+
+	rw := httptest.NewRecorder()
+	handler(rw, httptest.NewRequest("GET", "/", nil))
+	if rw.Code != StatusInternalServerError {
+		t.Errorf("Recover() code = %d, want %d", rw.Code, StatusInternalServerError)
+	}
+	if !reported {
+		t.Error("Recover() did not report panic")
+	}
+	if log := logs.String(); !strings.Contains(log, "uid") || !strings.Contains(log, "42") {
+		t.Errorf("Recover() log = %q, want fields of context", log)
+	}
+}
+
+func TestServer_recoverPanicFields(t *testing.T) {
+	var logs bytes.Buffer
+	SetLogOutput(&logs)
+	defer SetLogOutput(os.Stderr)
+
+	server := New()
+	server.AddInterceptor(func(h HandlerFunc) HandlerFunc {
+		return func(ctx Context) {
+			ctx.SetField("uid", "42")
+			h(ctx)
+		}
+	})
+	server.Get("/panic", func(ctx Context) {
+		ctx.SetField("order", "7")
+		panic("boom")
+	})
+
+	rw := httptest.NewRecorder()
+	server.ServeHTTP(rw, httptest.NewRequest("GET", "/panic", nil))
+	if rw.Code != StatusInternalServerError {
+		t.Errorf("Server.ServeHTTP() code = %d, want %d", rw.Code, StatusInternalServerError)
+	}
+	if log := logs.String(); !strings.Contains(log, "uid") || !strings.Contains(log, "42") || !strings.Contains(log, "order") {
+		t.Errorf("Server.ServeHTTP() log = %q, want fields of interceptor and handler", log)
+	}
+}
+
+func TestRecoverBufferResponse(t *testing.T) {
+	SetLogOutput(io.Discard)
+	defer SetLogOutput(os.Stderr)
+
+	panicking := func(ctx Context) {
+		ctx.WriteString("partial")
+		panic("boom")
+	}
+	server := New()
+	server.Get("/server", BufferResponse(1024)(panicking))
+	server.Get("/recover", Middlewares{Recover(nil), BufferResponse(1024)}.Wrap(panicking))
+	server.Get("/etag", ETag(false)(panicking))
+
+	for _, path := range []string{"/server", "/recover", "/etag"} {
+		t.Run(path, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			server.ServeHTTP(rw, httptest.NewRequest("GET", path, nil))
+			if rw.Code != StatusInternalServerError {
+				t.Errorf("Server.ServeHTTP() code = %d, want %d", rw.Code, StatusInternalServerError)
+			}
+			if strings.Contains(rw.Body.String(), "partial") {
+				t.Errorf("Server.ServeHTTP() body = %q, want buffered body discarded", rw.Body.String())
+			}
+		})
+	}
+}
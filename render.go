@@ -0,0 +1,308 @@
+package zen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// CSRFFieldName is the form field name of the hidden input written by csrfField template func
+const CSRFFieldName = "csrf_token"
+
+// ErrNoRenderer is returned by Context.HTML when Server has no Renderer
+var ErrNoRenderer = errors.New("zen: no renderer, use SetRenderer")
+
+// Renderer render named templates
+type Renderer interface {
+	// Render write template name executed with data to w
+	Render(w io.Writer, name string, data interface{}, ctx Context) error
+}
+
+// SetRenderer return Option for set the Renderer used by Context.HTML
+func SetRenderer(r Renderer) Option {
+	return func(s *Server) {
+		s.renderer = r
+	}
+}
+
+// HTML render template name with data, and write it with status code and html content type.
+// The response is not written if rendering fail, so the error can be passed to Context.Error.
+func (ctx *Context) HTML(code int, name string, data interface{}) error {
+	if ctx.server == nil || ctx.server.renderer == nil {
+		return ErrNoRenderer
+	}
+
+	var buf bytes.Buffer
+	if err := ctx.server.renderer.Render(&buf, name, data, *ctx); err != nil {
+		return err
+	}
+	ctx.WriteHeader(HeaderContentType, MIMETextHTMLCharsetUTF8)
+	ctx.WriteStatus(code)
+	_, err := buf.WriteTo(ctx.Rw)
+	return err
+}
+
+type csrfKey struct{}
+
+// SetCSRFToken set the token returned by CSRFToken and the csrfToken template func,
+// it is meant to be called by a CSRF middleware before calling the next handler
+func (ctx *Context) SetCSRFToken(token string) {
+	ctx.SetValue(csrfKey{}, token)
+}
+
+// CSRFToken return the token set by SetCSRFToken
+func (ctx *Context) CSRFToken() string {
+	token, _ := ctx.Value(csrfKey{}).(string)
+	return token
+}
+
+// BuildURL fill params of a route pattern in order, params are path escaped
+// except slashes of catch-all params
+func BuildURL(pattern string, params ...interface{}) (string, error) {
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		if segment == "" || (segment[0] != ':' && segment[0] != '*') {
+			continue
+		}
+		if len(params) == 0 {
+			return "", fmt.Errorf("zen: missing param %s of %q", segment, pattern)
+		}
+		value := fmt.Sprint(params[0])
+		params = params[1:]
+
+		if segment[0] == '*' {
+			parts := strings.Split(value, "/")
+			for j, part := range parts {
+				parts[j] = url.PathEscape(part)
+			}
+			segments[i] = strings.Join(parts, "/")
+			continue
+		}
+		segments[i] = url.PathEscape(value)
+	}
+	if len(params) > 0 {
+		return "", fmt.Errorf("zen: too many params for %q", pattern)
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+// HTMLOption customize HTMLRenderer
+type HTMLOption func(*HTMLRenderer)
+
+// HTMLExtension return HTMLOption for set the extension of template files, default is ".html"
+func HTMLExtension(ext string) HTMLOption {
+	return func(r *HTMLRenderer) {
+		r.ext = ext
+	}
+}
+
+// HTMLLayout return HTMLOption for set the layout every page is rendered in. The layout
+// include the page with {{template "content" .}}, and pages may define other blocks.
+func HTMLLayout(name string) HTMLOption {
+	return func(r *HTMLRenderer) {
+		r.layout = name
+	}
+}
+
+// HTMLSharedDirs return HTMLOption for set the directories of layouts and partials,
+// which are available to every page instead of being pages, default are "layouts" and "partials"
+func HTMLSharedDirs(dirs ...string) HTMLOption {
+	return func(r *HTMLRenderer) {
+		r.sharedDirs = dirs
+	}
+}
+
+// HTMLFuncs return HTMLOption for add funcs to templates
+func HTMLFuncs(funcs template.FuncMap) HTMLOption {
+	return func(r *HTMLRenderer) {
+		for name, fn := range funcs {
+			r.funcs[name] = fn
+		}
+	}
+}
+
+// HTMLReload return HTMLOption for reload templates on every render,
+// templates are always reloaded in debug mode
+func HTMLReload(b bool) HTMLOption {
+	return func(r *HTMLRenderer) {
+		r.reload = b
+	}
+}
+
+// HTMLRenderer is the default Renderer, which render html/template templates loaded
+// from a directory. Templates are named by their path without extension, e.g.
+// "users/show" for users/show.html. Besides funcs added by HTMLFuncs, templates can
+// call url to build a path from a route pattern, asset to resolve an asset URL with
+// Server.AssetURL, t to translate with Context.T, and csrfToken or csrfField to
+// include the token set by Context.SetCSRFToken.
+type HTMLRenderer struct {
+	fsys       fs.FS
+	ext        string
+	layout     string
+	sharedDirs []string
+	funcs      template.FuncMap
+	reload     bool
+
+	mu        sync.RWMutex
+	templates map[string]*template.Template
+}
+
+// NewHTMLRenderer create a HTMLRenderer of templates in dir
+func NewHTMLRenderer(dir string, options ...HTMLOption) (*HTMLRenderer, error) {
+	return NewHTMLRendererFS(os.DirFS(dir), options...)
+}
+
+// NewHTMLRendererFS create a HTMLRenderer of templates in fsys
+func NewHTMLRendererFS(fsys fs.FS, options ...HTMLOption) (*HTMLRenderer, error) {
+	r := &HTMLRenderer{
+		fsys:       fsys,
+		ext:        ".html",
+		sharedDirs: []string{"layouts", "partials"},
+		funcs: template.FuncMap{
+			"url":       BuildURL,
+			"asset":     func(name string) (string, error) { return name, nil },
+			"t":         fmt.Sprintf,
+			"csrfToken": func() string { return "" },
+			"csrfField": func() template.HTML { return "" },
+		},
+	}
+	for _, option := range options {
+		option(r)
+	}
+
+	templates, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	r.templates = templates
+	return r, nil
+}
+
+// Render write template name executed with data to w
+func (r *HTMLRenderer) Render(w io.Writer, name string, data interface{}, ctx Context) error {
+	templates, err := r.lookup(ctx)
+	if err != nil {
+		return err
+	}
+	t, ok := templates[name]
+	if !ok {
+		return fmt.Errorf("zen: template %q not found", name)
+	}
+
+	// templates are never executed so they can be cloned with funcs of ctx
+	t, err = t.Clone()
+	if err != nil {
+		return err
+	}
+	token := ctx.CSRFToken()
+	t.Funcs(template.FuncMap{
+		"asset":     ctx.assetURL,
+		"t":         ctx.T,
+		"csrfToken": func() string { return token },
+		"csrfField": func() template.HTML {
+			return template.HTML(`<input type="hidden" name="` + CSRFFieldName + `" value="` + template.HTMLEscapeString(token) + `">`)
+		},
+	})
+
+	entry := name
+	if r.layout != "" {
+		entry = r.layout
+	}
+	return t.ExecuteTemplate(w, entry, data)
+}
+
+// lookup return loaded templates, which are reloaded when reload is enabled or ctx is in debug mode
+func (r *HTMLRenderer) lookup(ctx Context) (map[string]*template.Template, error) {
+	if r.reload || ctx.Value(debugKey{}) != nil {
+		templates, err := r.load()
+		if err != nil {
+			return nil, err
+		}
+		r.mu.Lock()
+		r.templates = templates
+		r.mu.Unlock()
+		return templates, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.templates, nil
+}
+
+// load parse shared templates into every page
+func (r *HTMLRenderer) load() (map[string]*template.Template, error) {
+	var shared, pages []string
+	err := fs.WalkDir(r.fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(p) != r.ext {
+			return err
+		}
+		if r.isShared(p) {
+			shared = append(shared, p)
+		} else {
+			pages = append(pages, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	base := template.New("").Funcs(r.funcs)
+	for _, p := range shared {
+		if err := r.parse(base, strings.TrimSuffix(p, r.ext), p); err != nil {
+			return nil, err
+		}
+	}
+	if r.layout != "" && base.Lookup(r.layout) == nil {
+		return nil, fmt.Errorf("zen: layout %q not found", r.layout)
+	}
+
+	templates := make(map[string]*template.Template, len(pages))
+	for _, p := range pages {
+		t, err := base.Clone()
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimSuffix(p, r.ext)
+		// pages are included by layout as content
+		defined := name
+		if r.layout != "" {
+			defined = "content"
+		}
+		if err := r.parse(t, defined, p); err != nil {
+			return nil, err
+		}
+		templates[name] = t
+	}
+	return templates, nil
+}
+
+// isShared report whether template file p is in a shared directory
+func (r *HTMLRenderer) isShared(p string) bool {
+	for _, dir := range r.sharedDirs {
+		if strings.HasPrefix(p, strings.Trim(dir, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// parse add template file p into t as name
+func (r *HTMLRenderer) parse(t *template.Template, name, p string) error {
+	data, err := fs.ReadFile(r.fsys, p)
+	if err != nil {
+		return err
+	}
+	if _, err := t.New(name).Parse(string(data)); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package zen
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+type assetMap map[string]string
+
+func (m assetMap) AssetURL(name string) (string, bool) {
+	url, ok := m[name]
+	return url, ok
+}
+
+func TestContext_HTML(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html":  {Data: []byte(`<title>{{block "title" .}}zen{{end}}</title>{{template "partials/nav" .}}{{template "content" .}}`)},
+		"partials/nav.html":  {Data: []byte(`<a href="{{url "/users/:id" .ID}}">{{.Name}}</a>`)},
+		"users/show.html":    {Data: []byte(`{{define "title"}}{{.Name}}{{end}}<form>{{csrfField}}</form>`)},
+		"users/broken.html":  {Data: []byte(`{{index .Name 99}}`)},
+		"users/upper.html":   {Data: []byte(`{{upper .Name}}`)},
+		"users/asset.html":   {Data: []byte(`<script src="{{asset "app.js"}}"></script>`)},
+		"layouts/readme.txt": {Data: []byte(`ignored`)},
+	}
+	renderer, err := NewHTMLRendererFS(fsys, HTMLLayout("layouts/base"), HTMLFuncs(map[string]interface{}{"upper": strings.ToUpper}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := New(SetRenderer(renderer), SetAssetResolver(assetMap{"app.js": "/assets/app.3f2a.js"}))
+	server.Get("/users/:name", func(ctx Context) {
+		ctx.SetCSRFToken(`to"ken`)
+		data := map[string]interface{}{"ID": "a b", "Name": "<zen>"}
+		if err := ctx.HTML(StatusOK, "users/"+ctx.Param("name"), data); err != nil {
+			ctx.Error(err)
+		}
+	})
+
+	tests := []struct {
+		name string
+		code int
+		want string
+	}{
+		{"show", StatusOK, `<title>&lt;zen&gt;</title><a href="/users/a%20b">&lt;zen&gt;</a><form><input type="hidden" name="csrf_token" value="to&#34;ken"></form>`},
+		{"upper", StatusOK, `<title>zen</title><a href="/users/a%20b">&lt;zen&gt;</a>&lt;ZEN&gt;`},
+		{"asset", StatusOK, `<title>zen</title><a href="/users/a%20b">&lt;zen&gt;</a><script src="/assets/app.3f2a.js"></script>`},
+		{"broken", StatusInternalServerError, ""},
+		{"missing", StatusInternalServerError, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			server.ServeHTTP(rw, httptest.NewRequest("GET", "/users/"+tt.name, nil))
+			if rw.Code != tt.code {
+				t.Errorf("Context.HTML() code = %d, want %d", rw.Code, tt.code)
+			}
+			if tt.code != StatusOK {
+				return
+			}
+			if ct := rw.Header().Get(HeaderContentType); ct != MIMETextHTMLCharsetUTF8 {
+				t.Errorf("Context.HTML() Content-Type = %q, want %q", ct, MIMETextHTMLCharsetUTF8)
+			}
+			if rw.Body.String() != tt.want {
+				t.Errorf("Context.HTML() body = %q, want %q", rw.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLRenderer_reload(t *testing.T) {
+	fsys := fstest.MapFS{"index.html": {Data: []byte(`v1`)}}
+	renderer, err := NewHTMLRendererFS(fsys, HTMLReload(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf strings.Builder
+	fsys["index.html"] = &fstest.MapFile{Data: []byte(`v2`)}
+	if err := renderer.Render(&buf, "index", nil, getContext(nil, nil)); err != nil || buf.String() != "v2" {
+		t.Errorf("HTMLRenderer.Render() = %q, %v, want v2", buf.String(), err)
+	}
+
+	if _, err := NewHTMLRendererFS(fsys, HTMLLayout("layouts/base")); err == nil {
+		t.Error("NewHTMLRendererFS() error = nil for missing layout")
+	}
+	ctx := getContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if err := ctx.HTML(StatusOK, "index", nil); err != ErrNoRenderer {
+		t.Errorf("Context.HTML() error = %v, want %v", err, ErrNoRenderer)
+	}
+}
+
+func TestBuildURL(t *testing.T) {
+	tests := []struct {
+		pattern string
+		params  []interface{}
+		want    string
+		wantErr bool
+	}{
+		{"/users/:id", []interface{}{42}, "/users/42", false},
+		{"/files/*path", []interface{}{"a b/c?"}, "/files/a%20b/c%3F", false},
+		{"/static", nil, "/static", false},
+		{"/users/:id", nil, "", true},
+		{"/users", []interface{}{1}, "", true},
+	}
+	for _, tt := range tests {
+		got, err := BuildURL(tt.pattern, tt.params...)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("BuildURL(%q, %v) = %q, %v, want %q", tt.pattern, tt.params, got, err, tt.want)
+		}
+	}
+}
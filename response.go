@@ -0,0 +1,243 @@
+package zen
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+)
+
+// ensure responseWriter implement ResponseWriter
+var (
+	_ ResponseWriter = (*responseWriter)(nil)
+)
+
+// ErrNotHijacker is returned by Hijack when the underlying http.ResponseWriter does not support it
+var ErrNotHijacker = errors.New("zen: underlying ResponseWriter does not implement http.Hijacker")
+
+// ResponseWriter wraps http.ResponseWriter, it tracks the status code and size
+// of the response, and forwards optional interfaces of the underlying writer
+type ResponseWriter interface {
+	http.ResponseWriter
+	http.Flusher
+	http.Hijacker
+	http.Pusher
+	io.ReaderFrom
+	io.StringWriter
+
+	// Status return the status code written, 0 if the header is not written yet
+	Status() int
+
+	// Size return the number of body bytes written
+	Size() int
+
+	// Written report whether the status code has been written. For requests served
+	// by Server or UnWrapF the header is sent to the client with the first write of
+	// body, or when the handler returns, otherwise it is sent by WriteHeader
+	Written() bool
+
+	// Before register fn to be called right before the header is sent,
+	// so it can still modify the header, hooks are called in reverse order of registration
+	Before(fn func(ResponseWriter))
+
+	// Unwrap return the underlying http.ResponseWriter
+	Unwrap() http.ResponseWriter
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	status    int
+	size      int
+	committed bool
+	// deferred hold the header back until commit, it is only set by entry points
+	// which commit when the handler returns
+	deferred bool
+	before   []func(ResponseWriter)
+	// after hold funcs called by the entry point when the handler returns
+	after []func()
+}
+
+// newResponseWriter wrap rw into ResponseWriter, rw is returned as is if it is already a ResponseWriter
+func newResponseWriter(rw http.ResponseWriter) ResponseWriter {
+	if w, ok := rw.(ResponseWriter); ok {
+		return w
+	}
+	return &responseWriter{ResponseWriter: rw}
+}
+
+// baseWriter return the innermost http.ResponseWriter of rw
+func baseWriter(rw http.ResponseWriter) http.ResponseWriter {
+	for {
+		w, ok := rw.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return rw
+		}
+		rw = w.Unwrap()
+	}
+}
+
+// deferHeader make rw, if it is a responseWriter, hold the header back until commit
+func deferHeader(rw http.ResponseWriter) {
+	if w, ok := rw.(*responseWriter); ok {
+		w.deferred = true
+	}
+}
+
+// afterHandler register fn to be called when the handler writing to rw returns,
+// it is only called for writers of entry points which commit when the handler returns
+func afterHandler(rw http.ResponseWriter, fn func()) {
+	if w, ok := trackingWriter(rw); ok && w.deferred {
+		w.after = append(w.after, fn)
+	}
+}
+
+// handlerReturned call funcs registered with afterHandler in reverse order of registration
+func handlerReturned(rw http.ResponseWriter) {
+	w, ok := trackingWriter(rw)
+	if !ok {
+		return
+	}
+	after := w.after
+	w.after = nil
+	for i := len(after) - 1; i >= 0; i-- {
+		after[i]()
+	}
+}
+
+// WriteHeader record the status code, a deferred header is sent with the first write
+// of body, or by commit when the handler returns, others are sent right away
+func (w *responseWriter) WriteHeader(code int) {
+	if w.Written() {
+		return
+	}
+	// informational headers can be sent more than once before the final header
+	if code >= 100 && code < 200 && code != StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+	if !w.deferred {
+		w.commit()
+	}
+}
+
+// commit call before hooks and send the header to the client
+func (w *responseWriter) commit() {
+	if w.committed {
+		return
+	}
+	w.committed = true
+
+	before := w.before
+	w.before = nil
+	for i := len(before) - 1; i >= 0; i-- {
+		before[i](w)
+	}
+
+	if w.status == 0 {
+		w.status = StatusOK
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.commit()
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+func (w *responseWriter) WriteString(s string) (int, error) {
+	w.commit()
+	n, err := io.WriteString(w.ResponseWriter, s)
+	w.size += n
+	return n, err
+}
+
+func (w *responseWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.commit()
+
+	var (
+		n   int64
+		err error
+	)
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(r)
+	} else {
+		n, err = io.Copy(w.ResponseWriter, r)
+	}
+	w.size += int(n)
+	return n, err
+}
+
+func (w *responseWriter) Flush() {
+	w.commit()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, ErrNotHijacker
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil {
+		// the connection is taken over, no header should be written anymore
+		w.committed = true
+		if w.status == 0 {
+			w.status = StatusSwitchingProtocols
+		}
+	}
+	return conn, rw, err
+}
+
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+func (w *responseWriter) Status() int {
+	return w.status
+}
+
+func (w *responseWriter) Size() int {
+	return w.size
+}
+
+func (w *responseWriter) Written() bool {
+	return w.status != 0
+}
+
+func (w *responseWriter) Before(fn func(ResponseWriter)) {
+	w.before = append(w.before, fn)
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// trackingWriter return the innermost zen responseWriter of rw
+func trackingWriter(rw http.ResponseWriter) (*responseWriter, bool) {
+	for {
+		if w, ok := rw.(*responseWriter); ok {
+			return w, true
+		}
+		w, ok := rw.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil, false
+		}
+		rw = w.Unwrap()
+	}
+}
+
+// Response return the ResponseWriter of the context, ctx.Rw is wrapped if necessary
+func (ctx *Context) Response() ResponseWriter {
+	rw := newResponseWriter(ctx.Rw)
+	ctx.Rw = rw
+	return rw
+}
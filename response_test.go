@@ -0,0 +1,93 @@
+package zen
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseWriter(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	rw := newResponseWriter(recorder)
+
+	if rw.Written() || rw.Status() != 0 {
+		t.Errorf("ResponseWriter written before write, status %d", rw.Status())
+	}
+
+	rw.Before(func(w ResponseWriter) {
+		w.Header().Set(HeaderServer, "zen")
+	})
+	rw.WriteHeader(StatusCreated)
+	rw.WriteHeader(StatusAccepted)
+	rw.Write([]byte("zen"))
+	rw.WriteString("zen")
+	rw.ReadFrom(strings.NewReader("zen"))
+	rw.Flush()
+
+	if rw.Status() != StatusCreated || recorder.Code != StatusCreated {
+		t.Errorf("ResponseWriter status = %d, recorded %d, want %d", rw.Status(), recorder.Code, StatusCreated)
+	}
+	if rw.Size() != 9 || recorder.Body.String() != "zenzenzen" {
+		t.Errorf("ResponseWriter size = %d, body %s", rw.Size(), recorder.Body.String())
+	}
+	if recorder.Header().Get(HeaderServer) != "zen" {
+		t.Error("ResponseWriter before hook not called")
+	}
+	if !recorder.Flushed {
+		t.Error("ResponseWriter flush not forwarded")
+	}
+	if _, _, err := rw.Hijack(); err != ErrNotHijacker {
+		t.Errorf("ResponseWriter.Hijack() error = %v, want %v", err, ErrNotHijacker)
+	}
+	if err := rw.Push("/zen.js", nil); err != http.ErrNotSupported {
+		t.Errorf("ResponseWriter.Push() error = %v, want %v", err, http.ErrNotSupported)
+	}
+	if newResponseWriter(rw) != rw {
+		t.Error("ResponseWriter wrapped twice")
+	}
+}
+
+func TestContext_Response(t *testing.T) {
+	server := New()
+	var status, size int
+	server.AddInterceptor(func(h HandlerFunc) HandlerFunc {
+		return func(ctx Context) {
+			h(ctx)
+			status, size = ctx.Response().Status(), ctx.Response().Size()
+		}
+	})
+	server.Get("/zen", func(ctx Context) {
+		ctx.WriteStatus(StatusAccepted)
+		ctx.WriteString("zen")
+	})
+
+	server.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/zen", nil))
+	if status != StatusAccepted || size != 3 {
+		t.Errorf("Context.Response() status = %d size = %d, want %d %d", status, size, StatusAccepted, 3)
+	}
+}
+
+func TestContext_WriteStatusOutsideServer(t *testing.T) {
+	handler := func(ctx Context) {
+		ctx.WriteStatus(StatusNoContent)
+	}
+	tests := []struct {
+		name  string
+		serve func(rw http.ResponseWriter, req *http.Request)
+	}{
+		{"context", func(rw http.ResponseWriter, req *http.Request) {
+			handler(Context{Rw: rw, Req: req})
+		}},
+		{"unwrapf", UnWrapF(handler)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			tt.serve(rw, httptest.NewRequest("GET", "/", nil))
+			if rw.Code != StatusNoContent {
+				t.Errorf("Context.WriteStatus() code = %d, want %d", rw.Code, StatusNoContent)
+			}
+		})
+	}
+}
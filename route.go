@@ -2,7 +2,7 @@ package zen
 
 import (
 	"net/http"
-	"path"
+	"reflect"
 )
 
 const (
@@ -41,6 +41,20 @@ func (s *Server) methodRouteTree(method string) *node {
 	return methodRoot.node
 }
 
+// RouteInfo describe a registered route
+type RouteInfo struct {
+	Method string
+	Path   string
+	// In and Out are the input and output types of routes registered with Register
+	In  reflect.Type
+	Out reflect.Type
+}
+
+// routeDescriber record input and output types of a registered route
+type routeDescriber interface {
+	describeRoute(method, path string, in, out reflect.Type)
+}
+
 // Route set handler for given pattern and method
 func (s *Server) route(method string, path string, handler HandlerFunc) {
 	assert(path[0] == '/', "path must begin with '/'")
@@ -48,8 +62,36 @@ func (s *Server) route(method string, path string, handler HandlerFunc) {
 	assert(handler != nil, "handler cannot be nil")
 
 	handler = s.interceptors.Wrap(handler)
+	if s.debug {
+		handler = traceRoute(path, handler)
+	}
 	root := s.methodRouteTree(method)
 	root.addRoute(path, handler)
+	s.routes = append(s.routes, RouteInfo{Method: method, Path: path})
+}
+
+// Routes return all registered routes in registration order
+func (s *Server) Routes() []RouteInfo {
+	routes := make([]RouteInfo, len(s.routes))
+	copy(routes, s.routes)
+	return routes
+}
+
+// describeRoute set input and output types of a route registered in root group
+func (s *Server) describeRoute(method, path string, in, out reflect.Type) {
+	if d, ok := s.Router.(routeDescriber); ok {
+		d.describeRoute(method, path, in, out)
+	}
+}
+
+// setRouteTypes set input and output types of the route registered with method and full path
+func (s *Server) setRouteTypes(method, path string, in, out reflect.Type) {
+	for i := len(s.routes) - 1; i >= 0; i-- {
+		if s.routes[i].Method == method && s.routes[i].Path == path {
+			s.routes[i].In, s.routes[i].Out = in, out
+			return
+		}
+	}
 }
 
 // AddInterceptor add a global interceptor
@@ -57,30 +99,43 @@ func (s *Server) AddInterceptor(handler Middleware) {
 	s.Router.AddInterceptor(handler)
 }
 
-// Static :Adds a new Route for Static http requests. Serves
-// static files from the specified directory
-func (s *Server) Static(staticpath string, dir string) {
-	s.Route(GET, path.Join(staticpath, "/*filepath"), func(ctx Context) {
-		http.StripPrefix(staticpath, http.FileServer(http.Dir(dir))).ServeHTTP(ctx.Rw, ctx.Req)
-	})
-}
-
-// HandleNotFound set server's notFoundHandler
+// HandleNotFound set server's notFoundHandler, it is used for requests not under
+// a group with its own not found handler
 func (s *Server) HandleNotFound(handler HandlerFunc) {
 	s.notFoundHandler = handler
 }
 
-// HandleNotAllowed set server's methodNotAllowed
+// HandleNotAllowed set server's methodNotAllowed, it is used for requests not under
+// a group with its own method not allowed handler
 func (s *Server) HandleNotAllowed(handler HandlerFunc) {
 	s.methodNotAllowed = handler
 }
 
-// handleNotFound call server's not found handler
+// SetRedirectTrailingSlash set server's RedirectTrailingSlash
+func (s *Server) SetRedirectTrailingSlash(b bool) {
+	s.RedirectTrailingSlash = b
+}
+
+// SetRedirectFixedPath set server's RedirectFixedPath
+func (s *Server) SetRedirectFixedPath(b bool) {
+	s.RedirectFixedPath = b
+}
+
+// handleNotFound call not found handler of the group with longest base matching
+// request path, or server's not found handler
 func (s *Server) handleNotFound(ctx Context) {
+	if g := s.lookupGroup(ctx.Req.URL.Path, func(g *group) bool { return g.notFoundHandler != nil }); g != nil {
+		g.wrap(g.notFoundHandler)(ctx)
+		return
+	}
 	if s.notFoundHandler != nil {
 		s.notFoundHandler(ctx)
 		return
 	}
+	if s.statusHandler(ctx.Req.URL.Path, StatusNotFound) != nil {
+		ctx.WriteStatus(StatusNotFound)
+		return
+	}
 
 	http.NotFound(ctx.Rw, ctx.Req)
 }
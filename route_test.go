@@ -173,6 +173,10 @@ func TestRouterAPI(t *testing.T) {
 	if !handlerFunc {
 		t.Error("routing HandlerFunc failed")
 	}
+
+	if !interceptor {
+		t.Error("routing interceptor failed")
+	}
 }
 
 func TestRouterAPIAny(t *testing.T) {
@@ -484,6 +488,71 @@ func TestRouterNotFound(t *testing.T) {
 	}
 }
 
+func TestGroupNotFound(t *testing.T) {
+	handlerFunc := func(ctx Context) {}
+	text := func(body string) HandlerFunc {
+		return func(ctx Context) {
+			ctx.WriteStatus(StatusNotFound)
+			ctx.WriteString(body)
+		}
+	}
+
+	router := New()
+	router.HandleNotFound(text("site"))
+	router.HandleNotAllowed(func(ctx Context) {
+		ctx.WriteStatus(StatusMethodNotAllowed)
+		ctx.WriteString("site not allowed")
+	})
+	router.Get("/path/", handlerFunc)
+
+	api := router.Group("/api", func(h HandlerFunc) HandlerFunc {
+		return func(ctx Context) {
+			ctx.WriteHeader("X-Group", "api")
+			h(ctx)
+		}
+	})
+	api.HandleNotFound(text("api"))
+	api.HandleNotAllowed(func(ctx Context) {
+		ctx.WriteStatus(StatusMethodNotAllowed)
+		ctx.WriteString("api not allowed")
+	})
+	api.SetRedirectTrailingSlash(false)
+	api.Get("/user/", handlerFunc)
+
+	v2 := router.Group("/api/v2")
+	v2.HandleNotFound(text("v2"))
+
+	testRoutes := []struct {
+		method, route string
+		code          int
+		body, group   string
+	}{
+		{"GET", "/nope", 404, "site", ""},
+		{"GET", "/api/nope", 404, "api", "api"},
+		{"GET", "/api", 404, "api", "api"},
+		{"GET", "/apix", 404, "site", ""},
+		{"GET", "/api/v2/nope", 404, "v2", ""},
+		{"GET", "/api/user", 404, "api", "api"},
+		{"GET", "/path", 301, "", ""},
+		{"POST", "/api/user/", 405, "api not allowed", "api"},
+		{"POST", "/path/", 405, "site not allowed", ""},
+	}
+	for _, tr := range testRoutes {
+		r, _ := http.NewRequest(tr.method, tr.route, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != tr.code {
+			t.Errorf("Group NotFound route %s %s: Code=%d, want %d", tr.method, tr.route, w.Code, tr.code)
+		}
+		if tr.code != 301 && w.Body.String() != tr.body {
+			t.Errorf("Group NotFound route %s %s: Body=%q, want %q", tr.method, tr.route, w.Body.String(), tr.body)
+		}
+		if group := w.Header().Get("X-Group"); group != tr.group {
+			t.Errorf("Group NotFound route %s %s: X-Group=%q, want %q", tr.method, tr.route, group, tr.group)
+		}
+	}
+}
+
 func TestRouterLookup(t *testing.T) {
 	routed := false
 	wantHandle := func(ctx Context) {
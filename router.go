@@ -1,5 +1,7 @@
 package zen
 
+import "io/fs"
+
 // Router ...
 type Router interface {
 	// Route set handler for given pattern and method
@@ -37,4 +39,28 @@ type Router interface {
 
 	// AddInterceptor add a interceptor for given path
 	AddInterceptor(handler Middleware)
+
+	// HandleNotFound set handler for requests under the router's base which match no route
+	HandleNotFound(handler HandlerFunc)
+
+	// HandleNotAllowed set handler for requests under the router's base which match a route with another method
+	HandleNotAllowed(handler HandlerFunc)
+
+	// SetRedirectTrailingSlash override server's RedirectTrailingSlash for requests under the router's base
+	SetRedirectTrailingSlash(b bool)
+
+	// SetRedirectFixedPath override server's RedirectFixedPath for requests under the router's base
+	SetRedirectFixedPath(b bool)
+
+	// Static serve static files from dir under staticpath
+	Static(staticpath string, dir string, options ...StaticOption)
+
+	// StaticFS serve static files from fsys under staticpath
+	StaticFS(staticpath string, fsys fs.FS, options ...StaticOption)
+
+	// HandleStatus set handler to render responses finished with code and no body
+	HandleStatus(code int, handler HandlerFunc)
+
+	// HandleStatusRange set handler to render responses finished with a code between min and max and no body
+	HandleStatusRange(min, max int, handler HandlerFunc)
 }
@@ -0,0 +1,176 @@
+package zen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrStreamingUnsupported is returned when the response writer can not be flushed
+var ErrStreamingUnsupported = errors.New("zen: response writer does not support streaming")
+
+// ErrInvalidEventField is returned by EventStream.Send when event or id contain a line break or NUL
+var ErrInvalidEventField = errors.New("zen: event and id of server-sent event must not contain CR, LF or NUL")
+
+// EventStream write server-sent events, every write is flushed to the client.
+// It is safe for concurrent use, and stop writing once the request context is
+// done, which happens when the client disconnects.
+type EventStream struct {
+	ctx     Context
+	mu      sync.Mutex
+	closed  bool
+	stop    chan struct{}
+	stopped sync.WaitGroup
+}
+
+// SSE start a text/event-stream response and return its EventStream,
+// call Close of the stream before handler returns, the stream is closed
+// when the handler returns otherwise
+func (ctx *Context) SSE() (*EventStream, error) {
+	if _, ok := baseWriter(ctx.Rw).(http.Flusher); !ok {
+		return nil, ErrStreamingUnsupported
+	}
+
+	header := ctx.Rw.Header()
+	header.Set(HeaderContentType, MIMETextEventStream)
+	header.Set(HeaderCacheControl, "no-cache")
+	header.Del(HeaderContentLength)
+	// disable buffering of nginx
+	header.Set("X-Accel-Buffering", "no")
+	ctx.WriteStatus(StatusOK)
+	if f, ok := ctx.Rw.(http.Flusher); ok {
+		f.Flush()
+	}
+
+	stream := &EventStream{ctx: *ctx, stop: make(chan struct{})}
+	// heartbeats must not write once the handler returned
+	afterHandler(ctx.Rw, stream.Close)
+	return stream, nil
+}
+
+// LastEventID return Last-Event-ID header sent by a reconnecting client
+func (s *EventStream) LastEventID() string {
+	return s.ctx.Req.Header.Get(HeaderLastEventID)
+}
+
+// Done return a channel which is closed when the request context is done
+func (s *EventStream) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
+// Send write an event, event and id are omitted if empty, and ErrInvalidEventField is
+// returned if they contain CR, LF or NUL. data of string or []byte is sent as it is,
+// with one data field per line, other values are sent as json.
+func (s *EventStream) Send(event, id string, data interface{}) error {
+	if strings.ContainsAny(event, "\r\n\x00") || strings.ContainsAny(id, "\r\n\x00") {
+		return ErrInvalidEventField
+	}
+	var b strings.Builder
+	if event != "" {
+		b.WriteString("event: " + event + "\n")
+	}
+	if id != "" {
+		b.WriteString("id: " + id + "\n")
+	}
+
+	var payload string
+	switch v := data.(type) {
+	case string:
+		payload = v
+	case []byte:
+		payload = string(v)
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		payload = string(encoded)
+	}
+	for _, line := range splitLines(payload) {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+// Comment write a comment, which is ignored by clients but keep the connection alive
+func (s *EventStream) Comment(text string) error {
+	var b strings.Builder
+	for _, line := range splitLines(text) {
+		b.WriteString(": " + line + "\n")
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+// Retry tell the client how long to wait before reconnecting
+func (s *EventStream) Retry(d time.Duration) error {
+	return s.write(fmt.Sprintf("retry: %d\n\n", d.Milliseconds()))
+}
+
+// Heartbeat send an empty comment every interval until the stream is closed
+// or the request context is done
+func (s *EventStream) Heartbeat(interval time.Duration) {
+	s.stopped.Add(1)
+	go func() {
+		defer s.stopped.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if s.Comment("") != nil {
+					return
+				}
+			case <-s.stop:
+				return
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Close stop heartbeats, later writes fail with io.ErrClosedPipe
+func (s *EventStream) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	close(s.stop)
+	s.mu.Unlock()
+	s.stopped.Wait()
+}
+
+// write write and flush s unless the stream is closed or the request context is done
+func (s *EventStream) write(str string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return io.ErrClosedPipe
+	}
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(s.ctx.Rw, str); err != nil {
+		return err
+	}
+	if f, ok := s.ctx.Rw.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// splitLines split s on CRLF, CR and LF, which all end a line of an event stream
+func splitLines(s string) []string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.Split(strings.ReplaceAll(s, "\r", "\n"), "\n")
+}
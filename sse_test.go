@@ -0,0 +1,138 @@
+package zen
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContext_SSE(t *testing.T) {
+	server := New()
+	server.Get("/events", func(ctx Context) {
+		stream, err := ctx.SSE()
+		if err != nil {
+			t.Errorf("Context.SSE() error = %v", err)
+			return
+		}
+		defer stream.Close()
+
+		stream.Retry(3 * time.Second)
+		stream.Send("", "", "hello")
+		stream.Send("update", stream.LastEventID()+"-next", map[string]int{"count": 1})
+		stream.Send("multi", "1", "a\nb\r\nc\rid: injected")
+		if err := stream.Send("multi\nline", "", "x"); err != ErrInvalidEventField {
+			t.Errorf("EventStream.Send() error = %v, want %v", err, ErrInvalidEventField)
+		}
+		if err := stream.Send("", "1\r2", "x"); err != ErrInvalidEventField {
+			t.Errorf("EventStream.Send() error = %v, want %v", err, ErrInvalidEventField)
+		}
+		stream.Comment("ping\rdata: injected")
+	})
+
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	req, _ := http.NewRequest("GET", ts.URL+"/events", nil)
+	req.Header.Set(HeaderLastEventID, "41")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get(HeaderContentType); ct != MIMETextEventStream {
+		t.Errorf("Context.SSE() Content-Type = %q, want %q", ct, MIMETextEventStream)
+	}
+	if cc := resp.Header.Get(HeaderCacheControl); cc != "no-cache" {
+		t.Errorf("Context.SSE() Cache-Control = %q, want %q", cc, "no-cache")
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	want := "retry: 3000\n\n" +
+		"data: hello\n\n" +
+		"event: update\nid: 41-next\ndata: {\"count\":1}\n\n" +
+		"event: multi\nid: 1\ndata: a\ndata: b\ndata: c\ndata: id: injected\n\n" +
+		": ping\n: data: injected\n\n"
+	if string(body) != want {
+		t.Errorf("Context.SSE() body = %q, want %q", body, want)
+	}
+}
+
+func TestEventStream_disconnect(t *testing.T) {
+	done := make(chan error, 1)
+	server := New()
+	server.Get("/events", func(ctx Context) {
+		stream, err := ctx.SSE()
+		if err != nil {
+			done <- err
+			return
+		}
+		defer stream.Close()
+		stream.Heartbeat(10 * time.Millisecond)
+
+		for {
+			if err := stream.Send("tick", "", "data"); err != nil {
+				done <- err
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	reqCtx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(reqCtx, "GET", ts.URL+"/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line, _ := bufio.NewReader(resp.Body).ReadString('\n')
+	if !strings.HasPrefix(line, "event: tick") && !strings.HasPrefix(line, ":") {
+		t.Errorf("EventStream first line = %q", line)
+	}
+	cancel()
+	resp.Body.Close()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("EventStream.Send() error = nil after disconnect")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("EventStream.Send() did not stop after disconnect")
+	}
+}
+
+func TestEventStream_handlerReturned(t *testing.T) {
+	var stream *EventStream
+	server := New()
+	server.Get("/events", func(ctx Context) {
+		stream, _ = ctx.SSE()
+		stream.Heartbeat(time.Millisecond)
+	})
+
+	rw := httptest.NewRecorder()
+	server.ServeHTTP(rw, httptest.NewRequest("GET", "/events", nil))
+	size := rw.Body.Len()
+	time.Sleep(20 * time.Millisecond)
+	if rw.Body.Len() != size {
+		t.Error("EventStream.Heartbeat() wrote after handler returned")
+	}
+	if err := stream.Send("", "", "late"); err != io.ErrClosedPipe {
+		t.Errorf("EventStream.Send() error = %v, want %v after handler returned", err, io.ErrClosedPipe)
+	}
+}
+
+func TestContext_SSE_unsupported(t *testing.T) {
+	ctx := getContext(struct{ http.ResponseWriter }{httptest.NewRecorder()}, httptest.NewRequest("GET", "/", nil))
+	if _, err := ctx.SSE(); err != ErrStreamingUnsupported {
+		t.Errorf("Context.SSE() error = %v, want %v", err, ErrStreamingUnsupported)
+	}
+}
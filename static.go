@@ -0,0 +1,223 @@
+package zen
+
+import (
+	"errors"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// StaticOption customize Static and StaticFS
+type StaticOption func(*staticConfig)
+
+type staticConfig struct {
+	noBrowse       bool
+	hideDotFiles   bool
+	precompressed  bool
+	spaIndex       string
+	cacheControl   map[string]string
+	defaultControl string
+}
+
+// StaticBrowse return StaticOption for set whether directories without index.html are listed, default is true
+func StaticBrowse(b bool) StaticOption {
+	return func(c *staticConfig) {
+		c.noBrowse = !b
+	}
+}
+
+// StaticHideDotFiles return StaticOption for answer 404 for files and directories
+// whose name begin with a dot, they are also hidden from directory listings
+func StaticHideDotFiles(b bool) StaticOption {
+	return func(c *staticConfig) {
+		c.hideDotFiles = b
+	}
+}
+
+// StaticCacheControl return StaticOption for set Cache-Control of files with extension ext,
+// e.g. ".js", an empty ext set Cache-Control of files without a more specific value
+func StaticCacheControl(ext, value string) StaticOption {
+	return func(c *staticConfig) {
+		if ext == "" {
+			c.defaultControl = value
+			return
+		}
+		if c.cacheControl == nil {
+			c.cacheControl = make(map[string]string)
+		}
+		c.cacheControl[strings.ToLower(ext)] = value
+	}
+}
+
+// StaticPrecompressed return StaticOption for serve the .gz sibling of a file
+// with Content-Encoding gzip when the client accept it
+func StaticPrecompressed(b bool) StaticOption {
+	return func(c *staticConfig) {
+		c.precompressed = b
+	}
+}
+
+// StaticSPA return StaticOption for serve index, e.g. "index.html", for paths
+// which do not exist, so client side routes of a single-page app are served.
+// Missing files with an extension, e.g. "app.js", and requests which do not
+// accept html are still answered with 404.
+func StaticSPA(index string) StaticOption {
+	return func(c *staticConfig) {
+		c.spaIndex = strings.TrimPrefix(index, "/")
+	}
+}
+
+// Static serve static files from dir under staticpath
+func (s *Server) Static(staticpath string, dir string, options ...StaticOption) {
+	s.Router.Static(staticpath, dir, options...)
+}
+
+// StaticFS serve static files from fsys, e.g. an embed.FS, under staticpath
+func (s *Server) StaticFS(staticpath string, fsys fs.FS, options ...StaticOption) {
+	s.Router.StaticFS(staticpath, fsys, options...)
+}
+
+// Static serve static files from dir under staticpath of group's base
+func (g *group) Static(staticpath string, dir string, options ...StaticOption) {
+	g.StaticFS(staticpath, os.DirFS(dir), options...)
+}
+
+// StaticFS serve static files from fsys under staticpath of group's base
+func (g *group) StaticFS(staticpath string, fsys fs.FS, options ...StaticOption) {
+	var config staticConfig
+	for _, option := range options {
+		option(&config)
+	}
+	if config.hideDotFiles {
+		fsys = dotHiddenFS{fsys}
+	}
+	g.Get(path.Join(staticpath, "/*filepath"), config.handler(fsys))
+}
+
+// handler return the HandlerFunc serving fsys
+func (c *staticConfig) handler(fsys fs.FS) HandlerFunc {
+	fileServer := http.FileServer(http.FS(fsys))
+
+	return func(ctx Context) {
+		upath := path.Clean("/" + ctx.Param("filepath"))
+		name := strings.TrimPrefix(upath, "/")
+		if name == "" {
+			name = "."
+		}
+
+		info, err := fs.Stat(fsys, name)
+		if err == nil && info.IsDir() && c.noBrowse {
+			_, err = fs.Stat(fsys, path.Join(name, "index.html"))
+		}
+		if err != nil {
+			if c.spaIndex != "" && errors.Is(err, fs.ErrNotExist) && c.isClientRoute(ctx, upath) {
+				c.setCacheControl(ctx, c.spaIndex)
+				ctx.FileFS(fsys, c.spaIndex)
+				return
+			}
+			ctx.Error(fileError(err))
+			return
+		}
+
+		if !info.IsDir() {
+			c.setCacheControl(ctx, name)
+			if c.precompressed && c.serveCompressed(ctx, fsys, name) {
+				return
+			}
+		}
+
+		req := new(http.Request)
+		*req = *ctx.Req
+		u := *ctx.Req.URL
+		u.Path, u.RawPath = ctx.Param("filepath"), ""
+		req.URL = &u
+		fileServer.ServeHTTP(ctx.Rw, req)
+	}
+}
+
+// isClientRoute report whether a missing upath is a client side route of a single-page app
+func (c *staticConfig) isClientRoute(ctx Context, upath string) bool {
+	return path.Ext(upath) == "" && ctx.Negotiate(MIMETextHTML) != ""
+}
+
+// setCacheControl set Cache-Control configured for extension of name
+func (c *staticConfig) setCacheControl(ctx Context, name string) {
+	value, ok := c.cacheControl[strings.ToLower(path.Ext(name))]
+	if !ok {
+		value = c.defaultControl
+	}
+	if value != "" {
+		ctx.Rw.Header().Set(HeaderCacheControl, value)
+	}
+}
+
+// serveCompressed serve the .gz sibling of name if the client accept gzip, and report whether it is served
+func (c *staticConfig) serveCompressed(ctx Context, fsys fs.FS, name string) bool {
+	header := ctx.Rw.Header()
+	header.Add(HeaderVary, HeaderAcceptEncoding)
+	if negotiate(ctx.Req.Header.Get(HeaderAcceptEncoding), []string{"gzip"}) == "" {
+		return false
+	}
+	info, err := fs.Stat(fsys, name+".gz")
+	if err != nil || info.IsDir() {
+		return false
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = MIMEOctetStream
+	}
+	header.Set(HeaderContentType, contentType)
+	header.Set(HeaderContentEncoding, "gzip")
+	ctx.FileFS(fsys, name+".gz")
+	return true
+}
+
+// dotHiddenFS hide files and directories whose name begin with a dot
+type dotHiddenFS struct {
+	fs.FS
+}
+
+// Open return fs.ErrNotExist for hidden names
+func (h dotHiddenFS) Open(name string) (fs.File, error) {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") && part != "." {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+	}
+	f, err := h.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := f.Stat(); err == nil && info.IsDir() {
+		if dir, ok := f.(fs.ReadDirFile); ok {
+			return dotHiddenDir{dir}, nil
+		}
+	}
+	return f, nil
+}
+
+// dotHiddenDir filter hidden entries from directory listings
+type dotHiddenDir struct {
+	fs.ReadDirFile
+}
+
+// ReadDir return entries whose name do not begin with a dot
+func (d dotHiddenDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	for {
+		entries, err := d.ReadDirFile.ReadDir(n)
+		visible := entries[:0]
+		for _, entry := range entries {
+			if !strings.HasPrefix(entry.Name(), ".") {
+				visible = append(visible, entry)
+			}
+		}
+		// avoid returning an empty batch which is not the end
+		if len(visible) > 0 || err != nil || n <= 0 || len(entries) == 0 {
+			return visible, err
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package zen
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestServer_StaticFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.js":           {Data: []byte("js")},
+		"app.js.gz":        {Data: []byte("gzipped js")},
+		"style.css":        {Data: []byte("css")},
+		"index.html":       {Data: []byte("index")},
+		".env":             {Data: []byte("secret")},
+		"docs/readme.txt":  {Data: []byte("readme")},
+		"docs/.hidden.txt": {Data: []byte("hidden")},
+		"site/index.html":  {Data: []byte("site")},
+	}
+
+	server := New()
+	server.StaticFS("/public", fsys)
+	server.StaticFS("/assets", fsys,
+		StaticBrowse(false),
+		StaticHideDotFiles(true),
+		StaticPrecompressed(true),
+		StaticCacheControl(".js", "max-age=31536000"),
+		StaticCacheControl("", "no-cache"),
+	)
+	server.Group("/ui").StaticFS("/app", fsys, StaticSPA("index.html"))
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		code           int
+		body           string
+		encoding       string
+		cacheControl   string
+	}{
+		{"file", "/public/style.css", "", StatusOK, "css", "", ""},
+		{"dotfile", "/public/.env", "", StatusOK, "secret", "", ""},
+		{"listing", "/public/docs/", "", StatusOK, "", "", ""},
+		{"hidden dotfile", "/assets/.env", "", StatusNotFound, "", "", ""},
+		{"no listing", "/assets/docs/", "", StatusNotFound, "", "", ""},
+		{"directory index", "/assets/site/", "", StatusOK, "site", "", ""},
+		{"precompressed", "/assets/app.js", "gzip, deflate", StatusOK, "gzipped js", "gzip", "max-age=31536000"},
+		{"gzip not accepted", "/assets/app.js", "gzip;q=0", StatusOK, "js", "", "max-age=31536000"},
+		{"no sibling", "/assets/style.css", "gzip", StatusOK, "css", "", "no-cache"},
+		{"spa file", "/ui/app/style.css", "", StatusOK, "css", "", ""},
+		{"spa fallback", "/ui/app/users/42", "", StatusOK, "index", "", ""},
+		{"spa missing asset", "/ui/app/app.3f2a.js", "", StatusNotFound, "", "", ""},
+		{"spa missing icon", "/ui/app/favicon.ico", "", StatusNotFound, "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set(HeaderAcceptEncoding, tt.acceptEncoding)
+			}
+			rw := httptest.NewRecorder()
+			server.ServeHTTP(rw, req)
+
+			if rw.Code != tt.code {
+				t.Fatalf("StaticFS(%s) code = %d, want %d", tt.path, rw.Code, tt.code)
+			}
+			if tt.body != "" && rw.Body.String() != tt.body {
+				t.Errorf("StaticFS(%s) body = %q, want %q", tt.path, rw.Body.String(), tt.body)
+			}
+			if encoding := rw.Header().Get(HeaderContentEncoding); encoding != tt.encoding {
+				t.Errorf("StaticFS(%s) Content-Encoding = %q, want %q", tt.path, encoding, tt.encoding)
+			}
+			if cc := rw.Header().Get(HeaderCacheControl); cc != tt.cacheControl {
+				t.Errorf("StaticFS(%s) Cache-Control = %q, want %q", tt.path, cc, tt.cacheControl)
+			}
+		})
+	}
+
+	rw := httptest.NewRecorder()
+	server.ServeHTTP(rw, httptest.NewRequest("GET", "/assets/app.js", nil))
+	if ct := rw.Header().Get(HeaderContentType); ct != "text/javascript; charset=utf-8" {
+		t.Errorf("StaticFS() Content-Type = %q", ct)
+	}
+
+	for accept, code := range map[string]int{"text/html,*/*;q=0.8": StatusOK, MIMEApplicationJSON: StatusNotFound} {
+		req := httptest.NewRequest("GET", "/ui/app/users/42", nil)
+		req.Header.Set(HeaderAccept, accept)
+		rw := httptest.NewRecorder()
+		server.ServeHTTP(rw, req)
+		if rw.Code != code {
+			t.Errorf("StaticFS() SPA fallback with Accept %q code = %d, want %d", accept, rw.Code, code)
+		}
+	}
+}
+
+func TestStaticFS_hiddenListing(t *testing.T) {
+	fsys := fstest.MapFS{
+		"docs/readme.txt":  {Data: []byte("readme")},
+		"docs/.hidden.txt": {Data: []byte("hidden")},
+	}
+	server := New()
+	server.StaticFS("/files", fsys, StaticHideDotFiles(true))
+
+	rw := httptest.NewRecorder()
+	server.ServeHTTP(rw, httptest.NewRequest("GET", "/files/docs/", nil))
+	body := rw.Body.String()
+	if rw.Code != StatusOK || !strings.Contains(body, "readme.txt") || strings.Contains(body, ".hidden.txt") {
+		t.Errorf("StaticFS() listing = %d %q", rw.Code, body)
+	}
+}
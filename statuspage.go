@@ -0,0 +1,111 @@
+package zen
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+)
+
+// statusPage render responses finished with a status code in [min, max] and no body
+type statusPage struct {
+	min     int
+	max     int
+	handler HandlerFunc
+}
+
+// HandleStatus set handler to render responses finished with code and no body
+func (g *group) HandleStatus(code int, handler HandlerFunc) {
+	g.HandleStatusRange(code, code, handler)
+}
+
+// HandleStatusRange set handler to render responses finished with a code
+// between min and max inclusive and no body, e.g. HandleStatusRange(500, 599, h) for 5xx
+func (g *group) HandleStatusRange(min, max int, handler HandlerFunc) {
+	assert(min <= max, "min status code must not be greater than max")
+	assert(handler != nil, "handler cannot be nil")
+	g.statusPages = append(g.statusPages, statusPage{min: min, max: max, handler: handler})
+}
+
+// statusHandler return the handler of the narrowest range containing code,
+// later registration wins between ranges of same width
+func (g *group) statusHandler(code int) HandlerFunc {
+	var (
+		handler HandlerFunc
+		width   int
+	)
+	for _, page := range g.statusPages {
+		if code < page.min || code > page.max {
+			continue
+		}
+		if handler == nil || page.max-page.min <= width {
+			handler, width = page.handler, page.max-page.min
+		}
+	}
+	return handler
+}
+
+// statusHandler return the status handler for code of the group with longest base
+// matching path, handlers of the server's root group are used if no group match
+func (s *Server) statusHandler(path string, code int) HandlerFunc {
+	g := s.lookupGroup(path, func(g *group) bool {
+		return g.statusHandler(code) != nil
+	})
+	if g == nil {
+		return nil
+	}
+	return g.statusHandler(code)
+}
+
+// bodyAllowed report whether a response with code may have a body
+func bodyAllowed(code int) bool {
+	return code >= StatusOK && code != StatusNoContent && code != StatusNotModified
+}
+
+// finishResponse render the status page of responses finished without body,
+// and send the header if the handler did not write body
+func (s *Server) finishResponse(ctx Context, path string) {
+	w, ok := trackingWriter(ctx.Rw)
+	if !ok || w.committed {
+		return
+	}
+	if w.status != 0 && w.size == 0 && bodyAllowed(w.status) {
+		if s.debug && w.status >= StatusInternalServerError {
+			// fields set by interceptors and handler are not in ctx
+			ctx.withRequestFields()
+			ctx.renderDebugPage(w.status, nil)
+		} else if handler := s.statusHandler(path, w.status); handler != nil {
+			handler(ctx)
+		}
+	}
+	w.commit()
+}
+
+// StatusPage return a HandlerFunc which render the response status as problem json
+// or html according to Accept header, message is used as the detail of the page,
+// it can be used as status handler with HandleStatus
+func StatusPage(message string) HandlerFunc {
+	return func(ctx Context) {
+		code := ctx.Response().Status()
+		title := StatusText(code)
+
+		switch ctx.Negotiate(MIMEApplicationJSON, MIMEApplicationProblemJSON, MIMETextHTML) {
+		case MIMEApplicationJSON, MIMEApplicationProblemJSON:
+			ctx.WriteHeader(HeaderContentType, MIMEApplicationProblemJSON)
+			json.NewEncoder(ctx.Rw).Encode(Problem{
+				Type:     "about:blank",
+				Title:    title,
+				Status:   code,
+				Detail:   message,
+				Instance: ctx.Req.URL.Path,
+			})
+		case MIMETextHTML:
+			ctx.WriteHeader(HeaderContentType, MIMETextHTMLCharsetUTF8)
+			fmt.Fprintf(ctx.Rw, "<!DOCTYPE html>\n<html><head><title>%d %s</title></head><body><h1>%d %s</h1><p>%s</p></body></html>\n",
+				code, html.EscapeString(title), code, html.EscapeString(title), html.EscapeString(message))
+		default:
+			ctx.WriteHeader(HeaderContentType, MIMETextPlainCharsetUTF8)
+			io.WriteString(ctx.Rw, title+"\n")
+		}
+	}
+}
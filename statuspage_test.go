@@ -0,0 +1,115 @@
+package zen
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServer_HandleStatus(t *testing.T) {
+	text := func(body string) HandlerFunc {
+		return func(ctx Context) {
+			io.WriteString(ctx.Rw, body)
+		}
+	}
+
+	server := New()
+	server.HandleStatus(StatusNotFound, text("root not found"))
+	server.HandleStatusRange(500, 599, text("root 5xx"))
+	server.HandleStatus(StatusServiceUnavailable, text("root 503"))
+	server.HandleStatus(StatusMovedPermanently, text("moved"))
+	server.HandleStatus(StatusMethodNotAllowed, text("not allowed"))
+	server.Get("/error", func(ctx Context) {
+		ctx.WriteStatus(StatusInternalServerError)
+	})
+	server.Get("/unavailable", func(ctx Context) {
+		ctx.WriteStatus(StatusServiceUnavailable)
+	})
+	server.Get("/written", func(ctx Context) {
+		ctx.WriteStatus(StatusInternalServerError)
+		io.WriteString(ctx.Rw, "handler body")
+	})
+	server.Get("/created", func(ctx Context) {
+		ctx.WriteStatus(StatusCreated)
+	})
+	server.Get("/dir/", func(ctx Context) {})
+
+	api := server.Group("/api")
+	api.HandleStatus(StatusNotFound, text("api not found"))
+	api.Get("/error", func(ctx Context) {
+		ctx.WriteStatus(StatusBadGateway)
+	})
+
+	tests := []struct {
+		method, path string
+		code         int
+		body         string
+	}{
+		{"GET", "/missing", StatusNotFound, "root not found"},
+		{"GET", "/api/missing", StatusNotFound, "api not found"},
+		{"GET", "/apix", StatusNotFound, "root not found"},
+		{"GET", "/error", StatusInternalServerError, "root 5xx"},
+		{"GET", "/unavailable", StatusServiceUnavailable, "root 503"},
+		{"GET", "/api/error", StatusBadGateway, "root 5xx"},
+		{"GET", "/written", StatusInternalServerError, "handler body"},
+		{"GET", "/created", StatusCreated, ""},
+		{"GET", "/dir", StatusMovedPermanently, "moved"},
+		{"POST", "/error", StatusMethodNotAllowed, "not allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+tt.path, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			server.ServeHTTP(rw, httptest.NewRequest(tt.method, tt.path, nil))
+			if rw.Code != tt.code {
+				t.Errorf("Server.HandleStatus() code = %d, want %d", rw.Code, tt.code)
+			}
+			if body := rw.Body.String(); body != tt.body {
+				t.Errorf("Server.HandleStatus() body = %q, want %q", body, tt.body)
+			}
+		})
+	}
+
+	rw := httptest.NewRecorder()
+	server.ServeHTTP(rw, httptest.NewRequest("GET", "/dir", nil))
+	if location := rw.Header().Get(HeaderLocation); location != "/dir/" {
+		t.Errorf("Server.HandleStatus() location = %q, want %q", location, "/dir/")
+	}
+}
+
+func TestStatusPage(t *testing.T) {
+	server := New()
+	server.HandleStatusRange(400, 599, StatusPage("<try again>"))
+	server.Get("/error", func(ctx Context) {
+		ctx.WriteStatus(StatusInternalServerError)
+	})
+
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		contains    string
+	}{
+		{"default", "", MIMEApplicationProblemJSON, `"title":"Internal Server Error"`},
+		{"json", "application/json", MIMEApplicationProblemJSON, `"status":500`},
+		{"html", "text/html,application/xhtml+xml,*/*;q=0.8", MIMETextHTMLCharsetUTF8, "<p>&lt;try again&gt;</p>"},
+		{"other", "image/png", MIMETextPlainCharsetUTF8, "Internal Server Error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/error", nil)
+			req.Header.Set(HeaderAccept, tt.accept)
+			rw := httptest.NewRecorder()
+			server.ServeHTTP(rw, req)
+			if rw.Code != StatusInternalServerError {
+				t.Errorf("StatusPage() code = %d, want %d", rw.Code, StatusInternalServerError)
+			}
+			if ct := rw.Header().Get(HeaderContentType); ct != tt.contentType {
+				t.Errorf("StatusPage() content type = %q, want %q", ct, tt.contentType)
+			}
+			if body := rw.Body.String(); !strings.Contains(body, tt.contains) {
+				t.Errorf("StatusPage() body = %q, want contains %q", body, tt.contains)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package zen
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// StreamFormat is the format of a JSONStream
+type StreamFormat uint8
+
+const (
+	// StreamNDJSON write values as newline-delimited json
+	StreamNDJSON StreamFormat = iota
+	// StreamArray write values as elements of a json array
+	StreamArray
+)
+
+// DefaultStreamFlushInterval is the default interval a JSONStream flush written values
+const DefaultStreamFlushInterval = time.Second
+
+// JSONStream write json values one by one, so large result sets are not built in memory.
+// Written values are flushed to the client at most FlushInterval after they are
+// encoded, by a timer if no other value is encoded meanwhile, and writes fail with
+// the request context's error once the client disconnects.
+type JSONStream struct {
+	// FlushInterval is the max interval between flushes, values are flushed
+	// immediately if it is zero
+	FlushInterval time.Duration
+
+	ctx     Context
+	format  StreamFormat
+	encoder *json.Encoder
+	count   int
+	// mu guard writes against flushes of timer
+	mu     sync.Mutex
+	timer  *time.Timer
+	closed bool
+}
+
+// StreamJSON start a json response with status code 200 and return its JSONStream,
+// call Close of the stream to finish the response, the stream is closed when
+// the handler returns otherwise
+func (ctx *Context) StreamJSON(format StreamFormat) *JSONStream {
+	contentType := MIMEApplicationNDJSON
+	if format == StreamArray {
+		contentType = MIMEApplicationJSONCharsetUTF8
+	}
+	header := ctx.Rw.Header()
+	header.Set(HeaderContentType, contentType)
+	header.Del(HeaderContentLength)
+	ctx.WriteStatus(StatusOK)
+
+	stream := &JSONStream{
+		FlushInterval: DefaultStreamFlushInterval,
+		ctx:           *ctx,
+		format:        format,
+		encoder:       json.NewEncoder(ctx.Rw),
+	}
+	// the flush timer must not write once the handler returned
+	afterHandler(ctx.Rw, func() { stream.Close() })
+	return stream
+}
+
+// Encode write v to the stream
+func (s *JSONStream) Encode(v interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return io.ErrClosedPipe
+	}
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+
+	if s.format == StreamArray {
+		sep := ","
+		if s.count == 0 {
+			sep = "["
+		}
+		if _, err := io.WriteString(s.ctx.Rw, sep); err != nil {
+			return err
+		}
+	}
+	if err := s.encoder.Encode(v); err != nil {
+		return err
+	}
+	s.count++
+
+	if s.FlushInterval <= 0 {
+		s.flush()
+	} else if s.timer == nil {
+		s.timer = time.AfterFunc(s.FlushInterval, s.timedFlush)
+	}
+	return nil
+}
+
+// Flush send written values to the client
+func (s *JSONStream) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flush()
+}
+
+// timedFlush flush values encoded since the timer is started
+func (s *JSONStream) timedFlush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// the response is done once the stream is closed or handler returned
+	if !s.closed && s.ctx.Err() == nil {
+		s.flush()
+	}
+}
+
+func (s *JSONStream) flush() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if f, ok := s.ctx.Rw.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Count return the number of values written
+func (s *JSONStream) Count() int {
+	return s.count
+}
+
+// Close finish the stream and flush it, the closing bracket of an array
+// stream is written here
+func (s *JSONStream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+
+	if s.format == StreamArray {
+		end := "]\n"
+		if s.count == 0 {
+			end = "[]\n"
+		}
+		if _, err := io.WriteString(s.ctx.Rw, end); err != nil {
+			return err
+		}
+	}
+	s.flush()
+	return nil
+}
+
+// StreamDecoder read json values one by one from request body
+type StreamDecoder struct {
+	ctx     Context
+	decoder *json.Decoder
+	count   int
+}
+
+// DecodeStream return a StreamDecoder of request's newline-delimited json body,
+// the body is decoded as it is read instead of being loaded entirely
+func (ctx *Context) DecodeStream() *StreamDecoder {
+	ctx.rewindBody()
+	body := ctx.Req.Body
+	if body == nil {
+		body = http.NoBody
+	}
+	decoder := json.NewDecoder(body)
+	if ctx.strictJSON() {
+		decoder.DisallowUnknownFields()
+		decoder.UseNumber()
+	}
+	return &StreamDecoder{ctx: *ctx, decoder: decoder}
+}
+
+// Decode read the next value into v and validate it, io.EOF is returned when
+// there are no more values, errors caused by request body are returned as *BindError
+func (d *StreamDecoder) Decode(v interface{}) error {
+	if err := d.ctx.Err(); err != nil {
+		return err
+	}
+	if !d.decoder.More() {
+		_, err := d.decoder.Token()
+		switch err {
+		case io.EOF:
+			return io.EOF
+		case nil:
+			err = errTrailingData
+		}
+		return newBindError(err)
+	}
+	if err := d.decoder.Decode(v); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return newBindError(err)
+	}
+	d.count++
+	return validateStruct(v)
+}
+
+// Count return the number of values decoded
+func (d *StreamDecoder) Count() int {
+	return d.count
+}
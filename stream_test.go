@@ -0,0 +1,196 @@
+package zen
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContext_StreamJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      StreamFormat
+		values      []interface{}
+		contentType string
+		want        string
+	}{
+		{"ndjson", StreamNDJSON, []interface{}{1, "a", map[string]int{"b": 2}}, MIMEApplicationNDJSON, "1\n\"a\"\n{\"b\":2}\n"},
+		{"array", StreamArray, []interface{}{1, "a"}, MIMEApplicationJSONCharsetUTF8, "[1\n,\"a\"\n]\n"},
+		{"empty array", StreamArray, nil, MIMEApplicationJSONCharsetUTF8, "[]\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := New()
+			server.Get("/items", func(ctx Context) {
+				stream := ctx.StreamJSON(tt.format)
+				for _, v := range tt.values {
+					if err := stream.Encode(v); err != nil {
+						t.Errorf("JSONStream.Encode() error = %v", err)
+					}
+				}
+				stream.Close()
+			})
+
+			rw := httptest.NewRecorder()
+			server.ServeHTTP(rw, httptest.NewRequest("GET", "/items", nil))
+			if ct := rw.Header().Get(HeaderContentType); ct != tt.contentType {
+				t.Errorf("Context.StreamJSON() Content-Type = %q, want %q", ct, tt.contentType)
+			}
+			if rw.Body.String() != tt.want {
+				t.Errorf("Context.StreamJSON() body = %q, want %q", rw.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONStream_flushAndDisconnect(t *testing.T) {
+	done := make(chan error, 1)
+	server := New()
+	server.Get("/items", func(ctx Context) {
+		stream := ctx.StreamJSON(StreamNDJSON)
+		stream.FlushInterval = 0
+		defer stream.Close()
+		for i := 0; ; i++ {
+			if err := stream.Encode(i); err != nil {
+				done <- err
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	})
+
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	reqCtx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(reqCtx, "GET", ts.URL+"/items", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the first value is flushed before the handler returns
+	if line, _ := bufio.NewReader(resp.Body).ReadString('\n'); line != "0\n" {
+		t.Errorf("JSONStream first line = %q, want %q", line, "0\n")
+	}
+	cancel()
+	resp.Body.Close()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("JSONStream.Encode() error = nil after disconnect")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("JSONStream.Encode() did not stop after disconnect")
+	}
+}
+
+func TestJSONStream_timedFlush(t *testing.T) {
+	read := make(chan struct{})
+	server := New()
+	server.Get("/items", func(ctx Context) {
+		stream := ctx.StreamJSON(StreamNDJSON)
+		stream.FlushInterval = 10 * time.Millisecond
+		defer stream.Close()
+		stream.Encode(1)
+		// no more values until the first one is received
+		select {
+		case <-read:
+		case <-time.After(2 * time.Second):
+			t.Error("JSONStream did not flush after FlushInterval")
+		}
+	})
+
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	line, _ := bufio.NewReader(resp.Body).ReadString('\n')
+	close(read)
+	if line != "1\n" {
+		t.Errorf("JSONStream first line = %q, want %q", line, "1\n")
+	}
+}
+
+func TestJSONStream_handlerReturned(t *testing.T) {
+	var stream *JSONStream
+	server := New()
+	server.Get("/items", func(ctx Context) {
+		stream = ctx.StreamJSON(StreamArray)
+		stream.FlushInterval = time.Millisecond
+		stream.Encode(1)
+	})
+
+	rw := httptest.NewRecorder()
+	server.ServeHTTP(rw, httptest.NewRequest("GET", "/items", nil))
+	time.Sleep(10 * time.Millisecond)
+	if body := rw.Body.String(); body != "[1\n]\n" {
+		t.Errorf("JSONStream body = %q, want array closed when handler returned", body)
+	}
+	if err := stream.Encode(2); err != io.ErrClosedPipe {
+		t.Errorf("JSONStream.Encode() error = %v, want %v after handler returned", err, io.ErrClosedPipe)
+	}
+}
+
+func TestContext_DecodeStream(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+	}
+	tests := []struct {
+		name  string
+		body  string
+		names []string
+		kind  BindErrorKind
+		fail  bool
+	}{
+		{"ndjson", "{\"name\":\"a\"}\n{\"name\":\"b\"}\n", []string{"a", "b"}, 0, false},
+		{"empty", "", nil, 0, false},
+		{"blank lines", "\n{\"name\":\"a\"}\n\n", []string{"a"}, 0, false},
+		{"syntax", "{\"name\":\"a\"}\n{\"name\"", []string{"a"}, BindSyntax, true},
+		{"type", "{\"name\":1}\n", nil, BindType, true},
+		{"closing delimiter", "{\"name\":\"a\"}\n]", []string{"a"}, BindSyntax, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := getContext(httptest.NewRecorder(), httptest.NewRequest("POST", "/", strings.NewReader(tt.body)))
+			decoder := ctx.DecodeStream()
+
+			var names []string
+			var err error
+			for {
+				var v item
+				if err = decoder.Decode(&v); err != nil {
+					break
+				}
+				names = append(names, v.Name)
+			}
+
+			if strings.Join(names, ",") != strings.Join(tt.names, ",") {
+				t.Errorf("StreamDecoder.Decode() values = %v, want %v", names, tt.names)
+			}
+			if decoder.Count() != len(tt.names) {
+				t.Errorf("StreamDecoder.Count() = %d, want %d", decoder.Count(), len(tt.names))
+			}
+			if !tt.fail {
+				if err != io.EOF {
+					t.Errorf("StreamDecoder.Decode() error = %v, want %v", err, io.EOF)
+				}
+				return
+			}
+			var bindErr *BindError
+			if !errors.As(err, &bindErr) || bindErr.Kind != tt.kind {
+				t.Errorf("StreamDecoder.Decode() error = %v, want %s BindError", err, tt.kind)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package zen
+
+// Translator translate message keys into the locale of a request
+type Translator interface {
+	// Translate return the message of key formatted with args, false is returned if key is unknown
+	Translate(key string, args ...interface{}) (string, bool)
+}
+
+type translatorKey struct{}
+
+// SetTranslator set the Translator used by T, it is meant to be called by an
+// i18n middleware before calling the next handler
+func (ctx *Context) SetTranslator(t Translator) {
+	ctx.SetValue(translatorKey{}, t)
+}
+
+// Translator return the Translator set by SetTranslator, or nil
+func (ctx *Context) Translator() Translator {
+	t, _ := ctx.Value(translatorKey{}).(Translator)
+	return t
+}
+
+// T translate key with args, key is returned as it is if it can not be translated
+func (ctx *Context) T(key string, args ...interface{}) string {
+	if t := ctx.Translator(); t != nil {
+		if message, ok := t.Translate(key, args...); ok {
+			return message
+		}
+	}
+	return key
+}
+
+// localizeError translate the message of a ValidationError, so msg tags can reference catalog keys
+func (ctx *Context) localizeError(err error) error {
+	t := ctx.Translator()
+	if t == nil {
+		return err
+	}
+	if localized, ok := localize(t, err); ok {
+		return localized
+	}
+	return err
+}
+
+// localize return a copy of err with its ValidationError translated, PatchError and
+// HTTPError wrapping it are copied with the translated cause, other wrappers are not
+// translated. It report whether err is translated.
+func localize(t Translator, err error) (error, bool) {
+	switch e := err.(type) {
+	case *ValidationError:
+		if message, ok := t.Translate(e.Message); ok {
+			return &ValidationError{Field: e.Field, Message: message}, true
+		}
+	case *PatchError:
+		if cause, ok := localize(t, e.Err); ok {
+			patchErr := *e
+			patchErr.Err = cause
+			return &patchErr, true
+		}
+	case *HTTPError:
+		if cause, ok := localize(t, e.Err); ok {
+			httpErr := *e
+			httpErr.Err = cause
+			return &httpErr, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,169 @@
+package zen
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"net/http"
+	"reflect"
+)
+
+const pathTagName = "path"
+
+// Handle adapt a typed function into HandlerFunc.
+// In is bound from request body according to Content-Type (json, xml or form),
+// then fields with form tag are bound from query and form values, and fields with
+// path tag are bound from path params. The bound input is validated with valid tags.
+// Out is rendered as json or xml according to Accept header, with 201 Created for
+// POST requests, or the code returned by Out's StatusCode method, an empty struct
+// Out without StatusCode method is answered with 204 No Content.
+// Errors are passed to Context.Error.
+func Handle[In, Out any](fn func(ctx Context, in In) (Out, error)) HandlerFunc {
+	outType := reflect.TypeOf((*Out)(nil)).Elem()
+	noContent := outType.Kind() == reflect.Struct && outType.NumField() == 0 &&
+		!outType.Implements(reflect.TypeOf((*StatusCoder)(nil)).Elem())
+
+	return func(ctx Context) {
+		var in In
+		if err := ctx.bindInput(&in); err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		out, err := fn(ctx, in)
+		if err != nil {
+			ctx.Error(err)
+			return
+		}
+
+		if noContent {
+			ctx.WriteStatus(StatusNoContent)
+			return
+		}
+		if err := ctx.render(outputStatus(ctx.Req.Method, out), out); err != nil {
+			ctx.Error(err)
+		}
+	}
+}
+
+// Register add a route for fn with Handle, and record In and Out types in the
+// route table returned by Server.Routes
+func Register[In, Out any](r Router, method, path string, fn func(ctx Context, in In) (Out, error)) {
+	r.Route(method, path, Handle(fn))
+	if d, ok := r.(routeDescriber); ok {
+		d.describeRoute(method, path, reflect.TypeOf((*In)(nil)).Elem(), reflect.TypeOf((*Out)(nil)).Elem())
+	}
+}
+
+// outputStatus return the status code of a typed handler's output
+func outputStatus(method string, out interface{}) int {
+	if coder, ok := out.(StatusCoder); ok {
+		return coder.StatusCode()
+	}
+	if method == POST {
+		return StatusCreated
+	}
+	return StatusOK
+}
+
+// hasBody report whether request carries a body
+func hasBody(req *http.Request) bool {
+	return req.ContentLength > 0 || (req.ContentLength < 0 && req.Body != nil && req.Body != http.NoBody)
+}
+
+// bindInput bind request body, form values and path params into input and validate it
+func (ctx *Context) bindInput(input interface{}) error {
+	if hasBody(ctx.Req) {
+		mediaType, _, _ := mime.ParseMediaType(ctx.Req.Header.Get(HeaderContentType))
+		switch mediaType {
+		case MIMEApplicationJSON, "":
+			if err := ctx.BindJSON(input); err != nil {
+				return err
+			}
+		case MIMEApplicationXML, "text/xml":
+			if err := ctx.BindXML(input); err != nil {
+				return err
+			}
+		case MIMEApplicationForm, MIMEMultipartForm:
+		default:
+			return NewHTTPError(StatusUnsupportedMediaType, "unsupported content type "+mediaType)
+		}
+	}
+
+	inputValue := reflect.Indirect(reflect.ValueOf(input))
+	if inputValue.Kind() != reflect.Struct {
+		return nil
+	}
+
+	if !ctx.parsed {
+		if err := ctx.parseInput(); err != nil {
+			return newBindError(err)
+		}
+	}
+	inputType := inputValue.Type()
+	for i := 0; i < inputValue.NumField(); i++ {
+		if name := inputType.Field(i).Tag.Get(fileTagName); name != "" {
+			if err := ctx.bindFile(inputValue.Field(i), name); err != nil {
+				return err
+			}
+		}
+	}
+
+	err := bindTagged(inputValue, inputTagName, func(name string) (string, bool) {
+		values, ok := ctx.Req.Form[name]
+		if !ok || len(values) == 0 {
+			return "", false
+		}
+		return values[0], true
+	})
+	if err != nil {
+		return err
+	}
+	err = bindTagged(inputValue, pathTagName, func(name string) (string, bool) {
+		for _, p := range ctx.params {
+			if p.Key == name {
+				return p.Value, true
+			}
+		}
+		return "", false
+	})
+	if err != nil {
+		return err
+	}
+
+	return validateStruct(input)
+}
+
+// bindTagged scan values into fields of v with tag, fields without value are left untouched
+func bindTagged(v reflect.Value, tag string, lookup func(name string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		name := t.Field(i).Tag.Get(tag)
+		if name == "" {
+			continue
+		}
+		value, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		if err := scan(v.Field(i), value); err != nil {
+			return &BindError{Kind: BindType, Field: name, Err: err}
+		}
+	}
+	return nil
+}
+
+// render write v with code in the media type negotiated from Accept header
+func (ctx *Context) render(code int, v interface{}) error {
+	switch ctx.Negotiate(MIMEApplicationJSON, MIMEApplicationXML) {
+	case MIMEApplicationJSON:
+		ctx.WriteHeader(HeaderContentType, MIMEApplicationJSONCharsetUTF8)
+		ctx.WriteStatus(code)
+		return json.NewEncoder(ctx.Rw).Encode(v)
+	case MIMEApplicationXML:
+		ctx.WriteHeader(HeaderContentType, MIMEApplicationXMLCharsetUTF8)
+		ctx.WriteStatus(code)
+		return xml.NewEncoder(ctx.Rw).Encode(v)
+	}
+	return NewHTTPError(StatusNotAcceptable, "")
+}
@@ -0,0 +1,142 @@
+package zen
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type typedInput struct {
+	ID    int    `path:"id"`
+	Name  string `json:"name" form:"name" valid:"^[a-z]+$" msg:"illegal name"`
+	Limit int    `form:"limit"`
+}
+
+type typedOutput struct {
+	ID    int    `json:"id" xml:"id"`
+	Name  string `json:"name" xml:"name"`
+	Limit int    `json:"limit" xml:"limit"`
+}
+
+type acceptedOutput struct{}
+
+func (acceptedOutput) StatusCode() int {
+	return StatusAccepted
+}
+
+func TestHandle(t *testing.T) {
+	echo := func(ctx Context, in typedInput) (typedOutput, error) {
+		if in.ID == 0 {
+			return typedOutput{}, NewHTTPError(StatusNotFound, "")
+		}
+		return typedOutput{ID: in.ID, Name: in.Name, Limit: in.Limit}, nil
+	}
+
+	server := New()
+	Register(server, GET, "/users/:id", echo)
+	Register(server.Group("/api"), POST, "/users/:id", echo)
+	server.Delete("/users/:id", Handle(func(ctx Context, in typedInput) (struct{}, error) {
+		return struct{}{}, nil
+	}))
+	server.Put("/users/:id", Handle(func(ctx Context, in typedInput) (acceptedOutput, error) {
+		return acceptedOutput{}, nil
+	}))
+
+	tests := []struct {
+		name, method, path, contentType, accept, body string
+		code                                          int
+		want                                          string
+	}{
+		{"query", "GET", "/users/1?name=zen&limit=10", "", "", "", StatusOK, `{"id":1,"name":"zen","limit":10}`},
+		{"json body", "POST", "/api/users/2?limit=5", MIMEApplicationJSON, "", `{"name":"zen"}`, StatusCreated, `{"id":2,"name":"zen","limit":5}`},
+		{"xml output", "GET", "/users/1?name=zen", "", "application/xml", "", StatusOK, `<typedOutput><id>1</id><name>zen</name><limit>0</limit></typedOutput>`},
+		{"not acceptable", "GET", "/users/1?name=zen", "", "text/html", "", StatusNotAcceptable, ""},
+		{"invalid", "GET", "/users/1?name=ZEN", "", "", "", StatusUnprocessableEntity, ""},
+		{"bad param", "GET", "/users/one", "", "", "", StatusBadRequest, ""},
+		{"handler error", "GET", "/users/0?name=zen", "", "", "", StatusNotFound, ""},
+		{"unsupported body", "POST", "/api/users/2", "text/csv", "", "name\nzen", StatusUnsupportedMediaType, ""},
+		{"no content", "DELETE", "/users/1?name=zen", "", "", "", StatusNoContent, ""},
+		{"status coder", "PUT", "/users/1?name=zen", "", "", "", StatusAccepted, "{}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set(HeaderContentType, tt.contentType)
+			}
+			if tt.accept != "" {
+				req.Header.Set(HeaderAccept, tt.accept)
+			}
+			rw := httptest.NewRecorder()
+			server.ServeHTTP(rw, req)
+
+			if rw.Code != tt.code {
+				t.Errorf("Handle() code = %d, want %d, body %s", rw.Code, tt.code, rw.Body.String())
+			}
+			if tt.want != "" && strings.TrimSpace(rw.Body.String()) != tt.want {
+				t.Errorf("Handle() body = %s, want %s", rw.Body.String(), tt.want)
+			}
+		})
+	}
+
+	routes := server.Routes()
+	wantIn, wantOut := reflect.TypeOf(typedInput{}), reflect.TypeOf(typedOutput{})
+	for _, route := range routes {
+		if route.Path == "/api/users/:id" && (route.In != wantIn || route.Out != wantOut) {
+			t.Errorf("Register() route %s %s got types %v %v", route.Method, route.Path, route.In, route.Out)
+		}
+	}
+	if len(routes) != 4 || routes[0].In != wantIn {
+		t.Errorf("Server.Routes() = %v", routes)
+	}
+}
+
+func TestHandleNonStruct(t *testing.T) {
+	server := New()
+	server.Post("/sum", Handle(func(ctx Context, in []int) (int, error) {
+		if len(in) == 0 {
+			return 0, errors.New("empty")
+		}
+		sum := 0
+		for _, i := range in {
+			sum += i
+		}
+		return sum, nil
+	}))
+
+	rw := httptest.NewRecorder()
+	server.ServeHTTP(rw, httptest.NewRequest("POST", "/sum", strings.NewReader("[1,2,3]")))
+	var sum int
+	json.NewDecoder(rw.Body).Decode(&sum)
+	if rw.Code != StatusCreated || sum != 6 {
+		t.Errorf("Handle() got %d %d, want %d %d", rw.Code, sum, StatusCreated, 6)
+	}
+}
+
+func TestContext_Negotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		offers []string
+		want   string
+	}{
+		{"", []string{MIMEApplicationJSON, MIMEApplicationXML}, MIMEApplicationJSON},
+		{"application/xml", []string{MIMEApplicationJSON, MIMEApplicationXML}, MIMEApplicationXML},
+		{"application/*;q=0.5, application/xml", []string{MIMEApplicationJSON, MIMEApplicationXML}, MIMEApplicationXML},
+		{"text/html, */*;q=0.1", []string{MIMEApplicationJSON, MIMETextHTML}, MIMETextHTML},
+		{"*/*, application/json;q=0", []string{MIMEApplicationJSON, MIMEApplicationXML}, MIMEApplicationXML},
+		{"text/plain", []string{MIMEApplicationJSON}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set(HeaderAccept, tt.accept)
+			ctx := getContext(httptest.NewRecorder(), req)
+			if got := ctx.Negotiate(tt.offers...); got != tt.want {
+				t.Errorf("Context.Negotiate() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
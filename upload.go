@@ -0,0 +1,200 @@
+package zen
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+const (
+	fileTagName = "file"
+
+	// defaultMultipartMemory is the max bytes of multipart files kept in memory,
+	// the rest will be stored on disk in temporary files
+	defaultMultipartMemory = 32 << 20
+)
+
+var (
+	fileHeaderType  = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeadersType = reflect.TypeOf([]*multipart.FileHeader(nil))
+)
+
+// isMultipart report whether request's body is multipart/form-data
+func isMultipart(req *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get(HeaderContentType))
+	return err == nil && mediaType == MIMEMultipartForm
+}
+
+// MultipartForm parse request's multipart form, at most maxMemory bytes of
+// file parts will be stored in memory, the rest are stored in temporary files
+func (ctx *Context) MultipartForm(maxMemory int64) (*multipart.Form, error) {
+	if err := ctx.Req.ParseMultipartForm(maxMemory); err != nil {
+		return nil, err
+	}
+	ctx.parsed = true
+	return ctx.Req.MultipartForm, nil
+}
+
+// FormFile return the first uploaded file with given key
+func (ctx *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	form, err := ctx.MultipartForm(defaultMultipartMemory)
+	if err != nil {
+		return nil, err
+	}
+	if fhs := form.File[name]; len(fhs) > 0 {
+		return fhs[0], nil
+	}
+	return nil, http.ErrMissingFile
+}
+
+// SaveUploadedFile save uploaded file to dst, parent directories of dst will be created if not exist
+func (ctx *Context) SaveUploadedFile(fh *multipart.FileHeader, dst string) error {
+	src, err := fh.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+		return err
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	// a failed close may lose written data
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// MultipartReader return a multipart reader to process request's body as a stream,
+// it can not be used with MultipartForm, FormFile or ParseValidateForm in the same request
+func (ctx *Context) MultipartReader() (*multipart.Reader, error) {
+	return ctx.Req.MultipartReader()
+}
+
+// EachPart iterate over request's multipart body and call fn with every part,
+// the iteration stops at the first error returned by fn
+func (ctx *Context) EachPart(fn func(*multipart.Part) error) error {
+	reader, err := ctx.MultipartReader()
+	if err != nil {
+		return err
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		err = fn(part)
+		part.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// bindFile set uploaded files with given key into v,
+// v must be a *multipart.FileHeader or []*multipart.FileHeader
+func (ctx *Context) bindFile(v reflect.Value, name string) error {
+	if !v.CanSet() || ctx.Req.MultipartForm == nil {
+		return nil
+	}
+
+	fhs := ctx.Req.MultipartForm.File[name]
+	switch v.Type() {
+	case fileHeaderType:
+		if len(fhs) > 0 {
+			v.Set(reflect.ValueOf(fhs[0]))
+		}
+	case fileHeadersType:
+		v.Set(reflect.ValueOf(fhs))
+	default:
+		return errors.New("zen: file tag only support *multipart.FileHeader and []*multipart.FileHeader")
+	}
+	return nil
+}
+
+// LimitUpload return a Middleware which limit multipart uploads of a route,
+// it replace the server-wide MaxBodyBytes for the route.
+// Requests larger than maxSize are answered with 413 Request Entity Too Large,
+// requests which are not multipart or contain files whose content type is not
+// in allowTypes are answered with 415 Unsupported Media Type.
+// An allow type may be a full media type like "image/png" or a wildcard like "image/*",
+// if allowTypes is empty, files of any content type are accepted.
+// The multipart form is parsed before calling the handler, use EachPart without
+// LimitUpload if the body need to be processed as a stream.
+func LimitUpload(maxSize int64, allowTypes ...string) Middleware {
+	return func(h HandlerFunc) HandlerFunc {
+		return func(ctx Context) {
+			if ctx.Req.ContentLength > maxSize {
+				ctx.Error(NewHTTPError(StatusRequestEntityTooLarge, ""))
+				return
+			}
+			if !isMultipart(ctx.Req) {
+				ctx.Error(NewHTTPError(StatusUnsupportedMediaType, "request body must be "+MIMEMultipartForm))
+				return
+			}
+
+			ctx.limitBody(maxSize)
+			form, err := ctx.MultipartForm(defaultMultipartMemory)
+			if err != nil {
+				// errors not caused by size or content type mean a malformed body
+				code := ErrorStatus(err)
+				if code == StatusInternalServerError {
+					code = StatusBadRequest
+				}
+				ctx.Error(NewHTTPError(code, "").WithCause(err))
+				return
+			}
+
+			if len(allowTypes) > 0 {
+				for _, fhs := range form.File {
+					for _, fh := range fhs {
+						if !matchMediaType(fh.Header.Get(HeaderContentType), allowTypes) {
+							ctx.Error(NewHTTPError(StatusUnsupportedMediaType, "unsupported file type of "+fh.Filename))
+							return
+						}
+					}
+				}
+			}
+
+			h(ctx)
+		}
+	}
+}
+
+// matchMediaType report whether contentType matches one of patterns,
+// a pattern may be a full media type or a wildcard like "image/*" or "*/*"
+func matchMediaType(contentType string, patterns []string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		switch {
+		case pattern == "*/*" || pattern == mediaType:
+			return true
+		case strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mediaType, pattern[:len(pattern)-1]):
+			return true
+		}
+	}
+	return false
+}
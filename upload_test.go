@@ -0,0 +1,143 @@
+package zen
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type uploadFile struct {
+	field, name, contentType, content string
+}
+
+func newUploadRequest(t *testing.T, fields map[string]string, files ...uploadFile) *http.Request {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	for _, f := range files {
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", `form-data; name="`+f.field+`"; filename="`+f.name+`"`)
+		h.Set(HeaderContentType, f.contentType)
+		part, err := mw.CreatePart(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write([]byte(f.content))
+	}
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/upload", body)
+	req.Header.Set(HeaderContentType, mw.FormDataContentType())
+	return req
+}
+
+func TestContext_FormFile(t *testing.T) {
+	req := newUploadRequest(t, nil, uploadFile{"file", "zen.txt", MIMETextPlain, "zen"})
+	ctx := getContext(httptest.NewRecorder(), req)
+
+	fh, err := ctx.FormFile("file")
+	if err != nil {
+		t.Fatalf("Context.FormFile() error = %v", err)
+	}
+	if fh.Filename != "zen.txt" {
+		t.Errorf("Context.FormFile() filename = %s, want %s", fh.Filename, "zen.txt")
+	}
+
+	if _, err := ctx.FormFile("missing"); err != http.ErrMissingFile {
+		t.Errorf("Context.FormFile() error = %v, want %v", err, http.ErrMissingFile)
+	}
+
+	dir, err := ioutil.TempDir("", "zen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	dst := filepath.Join(dir, "sub", fh.Filename)
+	if err := ctx.SaveUploadedFile(fh, dst); err != nil {
+		t.Fatalf("Context.SaveUploadedFile() error = %v", err)
+	}
+	if data, _ := ioutil.ReadFile(dst); string(data) != "zen" {
+		t.Errorf("Context.SaveUploadedFile() saved %q, want %q", data, "zen")
+	}
+}
+
+func TestContext_ParseValidateFormFile(t *testing.T) {
+	req := newUploadRequest(t, map[string]string{"name": "zen"},
+		uploadFile{"avatar", "a.png", "image/png", "png"},
+		uploadFile{"docs", "a.txt", MIMETextPlain, "a"},
+		uploadFile{"docs", "b.txt", MIMETextPlain, "b"},
+	)
+	ctx := getContext(httptest.NewRecorder(), req)
+
+	var input struct {
+		Name   string                  `form:"name"`
+		Avatar *multipart.FileHeader   `file:"avatar"`
+		Docs   []*multipart.FileHeader `file:"docs"`
+	}
+	if err := ctx.ParseValidateForm(&input); err != nil {
+		t.Fatalf("Context.ParseValidateForm() error = %v", err)
+	}
+	if input.Name != "zen" {
+		t.Errorf("Context.ParseValidateForm() name = %s, want %s", input.Name, "zen")
+	}
+	if input.Avatar == nil || input.Avatar.Filename != "a.png" {
+		t.Errorf("Context.ParseValidateForm() avatar = %v, want a.png", input.Avatar)
+	}
+	if len(input.Docs) != 2 {
+		t.Errorf("Context.ParseValidateForm() got %d docs, want %d", len(input.Docs), 2)
+	}
+}
+
+func TestContext_EachPart(t *testing.T) {
+	req := newUploadRequest(t, map[string]string{"name": "zen"},
+		uploadFile{"file", "zen.txt", MIMETextPlain, "zen"},
+	)
+	ctx := getContext(httptest.NewRecorder(), req)
+
+	var names []string
+	err := ctx.EachPart(func(part *multipart.Part) error {
+		names = append(names, part.FormName())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Context.EachPart() error = %v", err)
+	}
+	if len(names) != 2 || names[0] != "name" || names[1] != "file" {
+		t.Errorf("Context.EachPart() got parts %v", names)
+	}
+}
+
+func TestLimitUpload(t *testing.T) {
+	handler := LimitUpload(1024, "image/*")(func(ctx Context) {
+		ctx.WriteStatus(StatusOK)
+	})
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		code int
+	}{
+		{"ok", newUploadRequest(t, nil, uploadFile{"file", "a.png", "image/png", "png"}), StatusOK},
+		{"too large", newUploadRequest(t, nil, uploadFile{"file", "a.png", "image/png", string(make([]byte, 2048))}), StatusRequestEntityTooLarge},
+		{"wrong type", newUploadRequest(t, nil, uploadFile{"file", "a.txt", MIMETextPlain, "txt"}), StatusUnsupportedMediaType},
+		{"not multipart", httptest.NewRequest("POST", "/upload", nil), StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			handler(getContext(rw, tt.req))
+			if rw.Code != tt.code {
+				t.Errorf("LimitUpload() code = %d, want %d", rw.Code, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,687 @@
+package zen
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// WebSocket message types
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+)
+
+// WebSocket close codes
+const (
+	CloseNormalClosure      = 1000
+	CloseGoingAway          = 1001
+	CloseProtocolError      = 1002
+	CloseUnsupportedData    = 1003
+	CloseNoStatusReceived   = 1005
+	CloseAbnormalClosure    = 1006
+	CloseInvalidPayloadData = 1007
+	ClosePolicyViolation    = 1008
+	CloseMessageTooBig      = 1009
+	CloseInternalServerErr  = 1011
+)
+
+const (
+	websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	// defaultReadLimit is the default max size of a received message
+	defaultReadLimit = 32 << 20
+	// closeTimeout is how long to wait for the peer's close frame
+	closeTimeout = time.Second
+	// fragmentSize is the max payload size of frames sent by WebSocket.NextWriter
+	fragmentSize = 4096
+	// compressThreshold is the min size of compressed messages
+	compressThreshold = 128
+)
+
+var (
+	// ErrReadLimit is returned when a received message exceed the read limit
+	ErrReadLimit = errors.New("zen: websocket message exceed read limit")
+	// ErrCloseSent is returned when writing after the close frame is sent
+	ErrCloseSent = errors.New("zen: websocket close frame sent")
+
+	deflateTail = []byte{0x00, 0x00, 0xff, 0xff}
+)
+
+// CloseError is returned by ReadMessage when the peer close the connection
+type CloseError struct {
+	Code int
+	Text string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("zen: websocket closed %d %s", e.Code, e.Text)
+}
+
+// WebSocketConfig configure WebSocket upgrades
+type WebSocketConfig struct {
+	// CheckOrigin return whether the request's Origin is allowed, by default
+	// requests without Origin or with Origin of the same host are allowed
+	CheckOrigin func(req *http.Request) bool
+
+	// Subprotocols supported by server in order of preference
+	Subprotocols []string
+
+	// ReadLimit is the max size of a received message, 32MB by default
+	ReadLimit int64
+
+	// EnableCompression negotiate permessage-deflate with clients which support it
+	EnableCompression bool
+}
+
+// SetWebSocketConfig return Option for set config of Context.Upgrade
+func SetWebSocketConfig(config WebSocketConfig) Option {
+	return func(s *Server) {
+		s.webSocketConfig = config
+	}
+}
+
+// WebSocket is a RFC 6455 server connection. A WebSocket support one concurrent
+// reader and multiple concurrent writers.
+type WebSocket struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	server      *Server
+	subprotocol string
+	compress    bool
+	readLimit   int64
+	pongHandler func(data []byte)
+
+	// writeMu serialize frames, messageMu serialize data messages
+	writeMu   sync.Mutex
+	messageMu sync.Mutex
+	closeSent bool
+	closeOnce sync.Once
+}
+
+// Upgrade upgrade the request to WebSocket with server's WebSocketConfig,
+// failed handshakes are answered with Context.Error
+func (ctx *Context) Upgrade() (*WebSocket, error) {
+	var config WebSocketConfig
+	if ctx.server != nil {
+		config = ctx.server.webSocketConfig
+	}
+	return ctx.UpgradeWith(config)
+}
+
+// UpgradeWith upgrade the request to WebSocket with config,
+// failed handshakes are answered with Context.Error
+func (ctx *Context) UpgradeWith(config WebSocketConfig) (*WebSocket, error) {
+	ws, err := ctx.upgrade(config)
+	if err != nil {
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) {
+			if httpErr.Code == StatusUpgradeRequired {
+				ctx.Rw.Header().Set("Sec-WebSocket-Version", "13")
+			}
+			ctx.Error(err)
+		}
+		return nil, err
+	}
+	return ws, nil
+}
+
+func (ctx *Context) upgrade(config WebSocketConfig) (*WebSocket, error) {
+	req := ctx.Req
+	switch {
+	case req.Method != GET:
+		return nil, NewHTTPError(StatusMethodNotAllowed, "websocket handshake must use GET")
+	case !headerContains(req.Header, "Connection", "upgrade") || !headerContains(req.Header, HeaderUpgrade, "websocket"):
+		return nil, NewHTTPError(StatusBadRequest, "not a websocket handshake")
+	case req.Header.Get("Sec-WebSocket-Version") != "13":
+		return nil, NewHTTPError(StatusUpgradeRequired, "unsupported websocket version")
+	}
+	key := req.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return nil, NewHTTPError(StatusBadRequest, "invalid Sec-WebSocket-Key")
+	}
+
+	checkOrigin := config.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(req) {
+		return nil, NewHTTPError(StatusForbidden, "origin not allowed")
+	}
+
+	ws := &WebSocket{
+		server:      ctx.server,
+		subprotocol: selectSubprotocol(req, config.Subprotocols),
+		compress:    config.EnableCompression && acceptDeflate(req),
+		readLimit:   config.ReadLimit,
+	}
+	if ws.readLimit <= 0 {
+		ws.readLimit = defaultReadLimit
+	}
+
+	conn, brw, err := ctx.Response().Hijack()
+	if err != nil {
+		return nil, NewHTTPError(StatusInternalServerError, "").WithCause(err)
+	}
+	// clear deadlines of ReadTimeout and WriteTimeout which are meant for http requests
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	ws.conn = conn
+	ws.br = brw.Reader
+
+	var resp bytes.Buffer
+	resp.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	resp.WriteString("Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n")
+	if ws.subprotocol != "" {
+		resp.WriteString("Sec-WebSocket-Protocol: " + ws.subprotocol + "\r\n")
+	}
+	if ws.compress {
+		resp.WriteString("Sec-WebSocket-Extensions: permessage-deflate; server_no_context_takeover; client_no_context_takeover\r\n")
+	}
+	resp.WriteString("\r\n")
+	if _, err := conn.Write(resp.Bytes()); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if ws.server != nil {
+		ws.server.trackWebSocket(ws, true)
+	}
+	return ws, nil
+}
+
+// headerContains report whether a comma separated header contains token
+func headerContains(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, field := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sameOrigin report whether req has no Origin or an Origin of the same host
+func sameOrigin(req *http.Request) bool {
+	origin := req.Header.Get(HeaderOrigin)
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, req.Host)
+}
+
+// selectSubprotocol return the first of supported protocols offered by the client
+func selectSubprotocol(req *http.Request, supported []string) string {
+	for _, protocol := range supported {
+		if headerContains(req.Header, "Sec-WebSocket-Protocol", protocol) {
+			return protocol
+		}
+	}
+	return ""
+}
+
+// acceptDeflate report whether the client offer permessage-deflate with parameters we can honor
+func acceptDeflate(req *http.Request) bool {
+	for _, value := range req.Header.Values("Sec-WebSocket-Extensions") {
+		for _, offer := range strings.Split(value, ",") {
+			params := strings.Split(offer, ";")
+			if strings.TrimSpace(params[0]) != "permessage-deflate" {
+				continue
+			}
+			ok := true
+			for _, param := range params[1:] {
+				name := strings.TrimSpace(strings.SplitN(param, "=", 2)[0])
+				switch name {
+				case "server_no_context_takeover", "client_no_context_takeover", "client_max_window_bits":
+				default:
+					// compress/flate always use a 32KB window
+					ok = false
+				}
+			}
+			if ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// acceptKey compute Sec-WebSocket-Accept of key
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// trackWebSocket add or remove ws from connections closed on Shutdown
+func (s *Server) trackWebSocket(ws *WebSocket, add bool) {
+	s.webSocketsMu.Lock()
+	defer s.webSocketsMu.Unlock()
+	if add {
+		if s.webSockets == nil {
+			s.webSockets = make(map[*WebSocket]struct{})
+		}
+		s.webSockets[ws] = struct{}{}
+		return
+	}
+	delete(s.webSockets, ws)
+}
+
+// closeWebSockets send close frames with code to all open connections
+func (s *Server) closeWebSockets(code int, reason string) {
+	s.webSocketsMu.Lock()
+	conns := make([]*WebSocket, 0, len(s.webSockets))
+	for ws := range s.webSockets {
+		conns = append(conns, ws)
+	}
+	s.webSocketsMu.Unlock()
+
+	for _, ws := range conns {
+		ws.Close(code, reason)
+	}
+}
+
+// Subprotocol return the negotiated subprotocol
+func (ws *WebSocket) Subprotocol() string {
+	return ws.subprotocol
+}
+
+// SetReadLimit set the max size of a received message
+func (ws *WebSocket) SetReadLimit(limit int64) {
+	ws.readLimit = limit
+}
+
+// SetPongHandler set handler of received pong frames
+func (ws *WebSocket) SetPongHandler(h func(data []byte)) {
+	ws.pongHandler = h
+}
+
+// SetReadDeadline set deadline of reading from the connection
+func (ws *WebSocket) SetReadDeadline(t time.Time) error {
+	return ws.conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline set deadline of writing to the connection
+func (ws *WebSocket) SetWriteDeadline(t time.Time) error {
+	return ws.conn.SetWriteDeadline(t)
+}
+
+// RemoteAddr return the remote address of the connection
+func (ws *WebSocket) RemoteAddr() net.Addr {
+	return ws.conn.RemoteAddr()
+}
+
+// frame is a received frame
+type frame struct {
+	fin     bool
+	rsv1    bool
+	opcode  int
+	payload []byte
+}
+
+// readFrame read a frame, payload larger than limit is not read
+func (ws *WebSocket) readFrame(limit int64) (frame, error) {
+	var f frame
+	var header [2]byte
+	if _, err := io.ReadFull(ws.br, header[:]); err != nil {
+		return f, err
+	}
+	f.fin = header[0]&0x80 != 0
+	f.rsv1 = header[0]&0x40 != 0
+	f.opcode = int(header[0] & 0x0f)
+	if header[0]&0x30 != 0 {
+		return f, ws.fail(CloseProtocolError, "reserved bits set")
+	}
+	if header[1]&0x80 == 0 {
+		return f, ws.fail(CloseProtocolError, "client frames must be masked")
+	}
+
+	length := int64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(ws.br, ext[:]); err != nil {
+			return f, err
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(ws.br, ext[:]); err != nil {
+			return f, err
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+		if length < 0 {
+			return f, ws.fail(CloseProtocolError, "invalid payload length")
+		}
+	}
+
+	if f.opcode >= CloseMessage {
+		// control frames are never compressed
+		if !f.fin || f.rsv1 || length > 125 {
+			return f, ws.fail(CloseProtocolError, "invalid control frame")
+		}
+	} else if length > limit {
+		return f, ws.failWith(CloseMessageTooBig, "", ErrReadLimit)
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(ws.br, mask[:]); err != nil {
+		return f, err
+	}
+	f.payload = make([]byte, length)
+	if _, err := io.ReadFull(ws.br, f.payload); err != nil {
+		return f, err
+	}
+	for i := range f.payload {
+		f.payload[i] ^= mask[i%4]
+	}
+	return f, nil
+}
+
+// ReadMessage read the next data message, ping frames are answered and pong frames
+// are passed to the pong handler while reading. A *CloseError is returned when the
+// peer close the connection, the close handshake is completed before returning.
+func (ws *WebSocket) ReadMessage() (messageType int, data []byte, err error) {
+	var (
+		buf        []byte
+		compressed bool
+	)
+	for {
+		f, err := ws.readFrame(ws.readLimit - int64(len(buf)))
+		if err != nil {
+			ws.closeConn()
+			return 0, nil, err
+		}
+
+		switch f.opcode {
+		case PingMessage:
+			if err := ws.writeFrame(true, false, PongMessage, f.payload); err != nil && err != ErrCloseSent {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			if ws.pongHandler != nil {
+				ws.pongHandler(f.payload)
+			}
+			continue
+		case CloseMessage:
+			return 0, nil, ws.receiveClose(f.payload)
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				return 0, nil, ws.fail(CloseProtocolError, "expect continuation frame")
+			}
+			if f.rsv1 && !ws.compress {
+				return 0, nil, ws.fail(CloseProtocolError, "unexpected compressed frame")
+			}
+			messageType, compressed = f.opcode, f.rsv1
+		case 0:
+			if messageType == 0 || f.rsv1 {
+				return 0, nil, ws.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+		default:
+			return 0, nil, ws.fail(CloseProtocolError, "unknown opcode")
+		}
+
+		buf = append(buf, f.payload...)
+		if !f.fin {
+			continue
+		}
+
+		if compressed {
+			r := flate.NewReader(io.MultiReader(bytes.NewReader(buf), bytes.NewReader(deflateTail)))
+			buf, err = io.ReadAll(io.LimitReader(r, ws.readLimit+1))
+			r.Close()
+			if err != nil && err != io.ErrUnexpectedEOF {
+				return 0, nil, ws.fail(CloseInvalidPayloadData, "invalid compressed data")
+			}
+			if int64(len(buf)) > ws.readLimit {
+				return 0, nil, ws.failWith(CloseMessageTooBig, "", ErrReadLimit)
+			}
+		}
+		if messageType == TextMessage && !utf8.Valid(buf) {
+			return 0, nil, ws.fail(CloseInvalidPayloadData, "invalid utf-8 text")
+		}
+		return messageType, buf, nil
+	}
+}
+
+// receiveClose answer the peer's close frame and close the connection
+func (ws *WebSocket) receiveClose(payload []byte) error {
+	closeErr := &CloseError{Code: CloseNoStatusReceived}
+	switch {
+	case len(payload) == 1:
+		return ws.fail(CloseProtocolError, "invalid close payload")
+	case len(payload) >= 2:
+		closeErr.Code = int(binary.BigEndian.Uint16(payload))
+		closeErr.Text = string(payload[2:])
+		if !validCloseCode(closeErr.Code) {
+			return ws.fail(CloseProtocolError, "invalid close code")
+		}
+		if !utf8.ValidString(closeErr.Text) {
+			return ws.fail(CloseInvalidPayloadData, "invalid utf-8 close reason")
+		}
+	}
+
+	// echo the status code
+	reply := CloseNormalClosure
+	if closeErr.Code != CloseNoStatusReceived {
+		reply = closeErr.Code
+	}
+	ws.writeClose(reply, "")
+	ws.closeConn()
+	return closeErr
+}
+
+// validCloseCode report whether code can be sent in a close frame
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1014, code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
+
+// fail close the connection with code after a protocol violation
+func (ws *WebSocket) fail(code int, reason string) error {
+	return ws.failWith(code, reason, &CloseError{Code: code, Text: reason})
+}
+
+func (ws *WebSocket) failWith(code int, reason string, err error) error {
+	ws.writeClose(code, reason)
+	ws.closeConn()
+	return err
+}
+
+// WriteMessage write a data or control message as a single frame
+func (ws *WebSocket) WriteMessage(messageType int, data []byte) error {
+	switch messageType {
+	case TextMessage, BinaryMessage:
+	case PingMessage, PongMessage:
+		if len(data) > 125 {
+			return errors.New("zen: websocket control frame payload too large")
+		}
+		return ws.writeFrame(true, false, messageType, data)
+	case CloseMessage:
+		return errors.New("zen: use WebSocket.Close to send close frame")
+	default:
+		return errors.New("zen: unknown websocket message type")
+	}
+
+	ws.messageMu.Lock()
+	defer ws.messageMu.Unlock()
+	if ws.compress && len(data) >= compressThreshold {
+		compressed, err := deflate(data)
+		if err != nil {
+			return err
+		}
+		return ws.writeFrame(true, true, messageType, compressed)
+	}
+	return ws.writeFrame(true, false, messageType, data)
+}
+
+// WriteJSON write v as a json text message
+func (ws *WebSocket) WriteJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return ws.WriteMessage(TextMessage, data)
+}
+
+// Ping send a ping frame
+func (ws *WebSocket) Ping(data []byte) error {
+	return ws.WriteMessage(PingMessage, data)
+}
+
+// NextWriter return a writer which send a data message in fragments, other data
+// messages are blocked until it is closed, the message is finished by Close
+func (ws *WebSocket) NextWriter(messageType int) (io.WriteCloser, error) {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return nil, errors.New("zen: websocket writer only support data messages")
+	}
+	ws.messageMu.Lock()
+	return &fragmentWriter{ws: ws, opcode: messageType}, nil
+}
+
+// fragmentWriter send a message in frames of fragmentSize
+type fragmentWriter struct {
+	ws     *WebSocket
+	opcode int
+	buf    []byte
+	closed bool
+}
+
+func (w *fragmentWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, io.ErrClosedPipe
+	}
+	n := len(p)
+	for len(w.buf)+len(p) > fragmentSize {
+		chunk := fragmentSize - len(w.buf)
+		w.buf = append(w.buf, p[:chunk]...)
+		p = p[chunk:]
+		if err := w.flush(false); err != nil {
+			return 0, err
+		}
+	}
+	w.buf = append(w.buf, p...)
+	return n, nil
+}
+
+func (w *fragmentWriter) flush(fin bool) error {
+	err := w.ws.writeFrame(fin, false, w.opcode, w.buf)
+	// following frames are continuations
+	w.opcode = 0
+	w.buf = w.buf[:0]
+	return err
+}
+
+func (w *fragmentWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	defer w.ws.messageMu.Unlock()
+	return w.flush(true)
+}
+
+// writeFrame write a single unmasked frame
+func (ws *WebSocket) writeFrame(fin, rsv1 bool, opcode int, payload []byte) error {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	if ws.closeSent {
+		return ErrCloseSent
+	}
+	if opcode == CloseMessage {
+		ws.closeSent = true
+	}
+
+	header := make([]byte, 2, 10+len(payload))
+	header[0] = byte(opcode)
+	if fin {
+		header[0] |= 0x80
+	}
+	if rsv1 {
+		header[0] |= 0x40
+	}
+	switch length := len(payload); {
+	case length <= 125:
+		header[1] = byte(length)
+	case length <= 0xffff:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(length))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(length))
+	}
+	_, err := ws.conn.Write(append(header, payload...))
+	return err
+}
+
+// writeClose send a close frame with code and reason
+func (ws *WebSocket) writeClose(code int, reason string) error {
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+	if len(payload) > 125 {
+		payload = payload[:125]
+	}
+	return ws.writeFrame(true, false, CloseMessage, payload)
+}
+
+// Close start the close handshake with code and reason, the connection is closed
+// when the reader receive the peer's close frame, or after a timeout
+func (ws *WebSocket) Close(code int, reason string) error {
+	err := ws.writeClose(code, reason)
+	if err == ErrCloseSent {
+		err = nil
+	}
+	time.AfterFunc(closeTimeout, ws.closeConn)
+	return err
+}
+
+// closeConn close the underlying connection
+func (ws *WebSocket) closeConn() {
+	ws.closeOnce.Do(func() {
+		ws.conn.Close()
+		if ws.server != nil {
+			ws.server.trackWebSocket(ws, false)
+		}
+	})
+}
+
+// deflate compress data for permessage-deflate without the trailing empty block
+func deflate(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), deflateTail), nil
+}
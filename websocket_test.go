@@ -0,0 +1,314 @@
+package zen
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wsClient is a minimal websocket client for tests
+type wsClient struct {
+	t    *testing.T
+	conn net.Conn
+	br   *bufio.Reader
+	resp *http.Response
+}
+
+func dialWebSocket(t *testing.T, url string, header http.Header) *wsClient {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(url, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("GET", url+"/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set(HeaderUpgrade, "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if err := req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	return &wsClient{t: t, conn: conn, br: br, resp: resp}
+}
+
+func (c *wsClient) writeFrame(fin, rsv1 bool, opcode int, payload []byte) {
+	b0 := byte(opcode)
+	if fin {
+		b0 |= 0x80
+	}
+	if rsv1 {
+		b0 |= 0x40
+	}
+	frame := []byte{b0}
+	switch {
+	case len(payload) <= 125:
+		frame = append(frame, 0x80|byte(len(payload)))
+	case len(payload) <= 0xffff:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := c.conn.Write(frame); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *wsClient) readFrame() (fin, rsv1 bool, opcode int, payload []byte) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		c.t.Fatal(err)
+	}
+	length := int(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		io.ReadFull(c.br, ext[:])
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		io.ReadFull(c.br, ext[:])
+		length = int(binary.BigEndian.Uint64(ext[:]))
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		c.t.Fatal(err)
+	}
+	return header[0]&0x80 != 0, header[0]&0x40 != 0, int(header[0] & 0x0f), payload
+}
+
+func (c *wsClient) expectClose(code int) {
+	_, _, opcode, payload := c.readFrame()
+	if opcode != CloseMessage || len(payload) < 2 || int(binary.BigEndian.Uint16(payload)) != code {
+		c.t.Errorf("close frame = %d %v, want close %d", opcode, payload, code)
+	}
+}
+
+func closePayload(code int) []byte {
+	return binary.BigEndian.AppendUint16(nil, uint16(code))
+}
+
+func newWebSocketServer(t *testing.T, config WebSocketConfig) (*Server, *httptest.Server, chan error) {
+	done := make(chan error, 1)
+	server := New(SetWebSocketConfig(config))
+	server.Get("/ws", func(ctx Context) {
+		ws, err := ctx.Upgrade()
+		if err != nil {
+			return
+		}
+		for {
+			messageType, data, err := ws.ReadMessage()
+			if err != nil {
+				done <- err
+				return
+			}
+			if string(data) == "fragment" {
+				w, _ := ws.NextWriter(TextMessage)
+				w.Write(bytes.Repeat([]byte("z"), fragmentSize+10))
+				w.Close()
+				continue
+			}
+			ws.WriteMessage(messageType, data)
+		}
+	})
+	return server, httptest.NewServer(server), done
+}
+
+func TestContext_Upgrade_handshake(t *testing.T) {
+	_, ts, _ := newWebSocketServer(t, WebSocketConfig{Subprotocols: []string{"v2", "v1"}})
+	defer ts.Close()
+
+	tests := []struct {
+		name        string
+		header      http.Header
+		code        int
+		subprotocol string
+	}{
+		{"ok", nil, StatusSwitchingProtocols, ""},
+		{"subprotocol", http.Header{"Sec-Websocket-Protocol": {"v1, v2"}}, StatusSwitchingProtocols, "v2"},
+		{"same origin", http.Header{"Origin": {ts.URL}}, StatusSwitchingProtocols, ""},
+		{"cross origin", http.Header{"Origin": {"http://evil.example"}}, StatusForbidden, ""},
+		{"version", http.Header{"Sec-Websocket-Version": {"8"}}, StatusUpgradeRequired, ""},
+		{"key", http.Header{"Sec-Websocket-Key": {"short"}}, StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := dialWebSocket(t, ts.URL, tt.header)
+			defer c.conn.Close()
+			if c.resp.StatusCode != tt.code {
+				t.Fatalf("Context.Upgrade() code = %d, want %d", c.resp.StatusCode, tt.code)
+			}
+			if tt.code != StatusSwitchingProtocols {
+				return
+			}
+			if accept := c.resp.Header.Get("Sec-WebSocket-Accept"); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+				t.Errorf("Context.Upgrade() Sec-WebSocket-Accept = %q", accept)
+			}
+			if protocol := c.resp.Header.Get("Sec-WebSocket-Protocol"); protocol != tt.subprotocol {
+				t.Errorf("Context.Upgrade() subprotocol = %q, want %q", protocol, tt.subprotocol)
+			}
+		})
+	}
+}
+
+func TestWebSocket_messages(t *testing.T) {
+	_, ts, done := newWebSocketServer(t, WebSocketConfig{ReadLimit: 1 << 20})
+	defer ts.Close()
+
+	c := dialWebSocket(t, ts.URL, nil)
+	defer c.conn.Close()
+
+	// echo
+	c.writeFrame(true, false, TextMessage, []byte("hello"))
+	if _, _, opcode, payload := c.readFrame(); opcode != TextMessage || string(payload) != "hello" {
+		t.Errorf("echo = %d %q, want text hello", opcode, payload)
+	}
+
+	// fragmented message with interleaved ping
+	c.writeFrame(false, false, BinaryMessage, []byte("ab"))
+	c.writeFrame(true, false, PingMessage, []byte("p"))
+	if _, _, opcode, payload := c.readFrame(); opcode != PongMessage || string(payload) != "p" {
+		t.Errorf("pong = %d %q, want pong p", opcode, payload)
+	}
+	c.writeFrame(true, false, 0, []byte("cd"))
+	if _, _, opcode, payload := c.readFrame(); opcode != BinaryMessage || string(payload) != "abcd" {
+		t.Errorf("fragmented echo = %d %q, want binary abcd", opcode, payload)
+	}
+
+	// fragmented write
+	c.writeFrame(true, false, TextMessage, []byte("fragment"))
+	var message []byte
+	for i := 0; ; i++ {
+		fin, _, opcode, payload := c.readFrame()
+		if (i == 0) != (opcode == TextMessage) {
+			t.Fatalf("fragment %d opcode = %d", i, opcode)
+		}
+		message = append(message, payload...)
+		if fin {
+			break
+		}
+	}
+	if len(message) != fragmentSize+10 {
+		t.Errorf("fragmented write length = %d, want %d", len(message), fragmentSize+10)
+	}
+
+	// close handshake
+	c.writeFrame(true, false, CloseMessage, append(closePayload(CloseNormalClosure), "bye"...))
+	c.expectClose(CloseNormalClosure)
+	var closeErr *CloseError
+	if err := <-done; !errors.As(err, &closeErr) || closeErr.Code != CloseNormalClosure || closeErr.Text != "bye" {
+		t.Errorf("WebSocket.ReadMessage() error = %v, want close 1000 bye", err)
+	}
+}
+
+func TestWebSocket_violations(t *testing.T) {
+	tests := []struct {
+		name  string
+		send  func(c *wsClient)
+		code  int
+		limit bool
+	}{
+		{"unmasked", func(c *wsClient) { c.conn.Write([]byte{0x81, 0x00}) }, CloseProtocolError, false},
+		{"continuation", func(c *wsClient) { c.writeFrame(true, false, 0, []byte("x")) }, CloseProtocolError, false},
+		{"invalid utf8", func(c *wsClient) { c.writeFrame(true, false, TextMessage, []byte{0xff}) }, CloseInvalidPayloadData, false},
+		{"too big", func(c *wsClient) { c.writeFrame(true, false, BinaryMessage, make([]byte, 200)) }, CloseMessageTooBig, true},
+		{"compressed ping", func(c *wsClient) { c.writeFrame(true, true, PingMessage, []byte("p")) }, CloseProtocolError, false},
+		{"invalid close code", func(c *wsClient) { c.writeFrame(true, false, CloseMessage, closePayload(1005)) }, CloseProtocolError, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ts, done := newWebSocketServer(t, WebSocketConfig{ReadLimit: 100})
+			defer ts.Close()
+			c := dialWebSocket(t, ts.URL, nil)
+			defer c.conn.Close()
+
+			tt.send(c)
+			c.expectClose(tt.code)
+			if err := <-done; (err == ErrReadLimit) != tt.limit {
+				t.Errorf("WebSocket.ReadMessage() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestWebSocket_serverTimeouts(t *testing.T) {
+	server, unused, _ := newWebSocketServer(t, WebSocketConfig{})
+	unused.Close()
+	ts := httptest.NewUnstartedServer(server)
+	ts.Config.ReadTimeout = 50 * time.Millisecond
+	ts.Config.WriteTimeout = 50 * time.Millisecond
+	ts.Start()
+	defer ts.Close()
+
+	c := dialWebSocket(t, ts.URL, nil)
+	defer c.conn.Close()
+	time.Sleep(150 * time.Millisecond)
+	c.writeFrame(true, false, TextMessage, []byte("hello"))
+	if _, _, opcode, payload := c.readFrame(); opcode != TextMessage || string(payload) != "hello" {
+		t.Errorf("echo after server timeouts = %d %q, want text hello", opcode, payload)
+	}
+}
+
+func TestWebSocket_compression(t *testing.T) {
+	_, ts, _ := newWebSocketServer(t, WebSocketConfig{EnableCompression: true})
+	defer ts.Close()
+
+	c := dialWebSocket(t, ts.URL, http.Header{"Sec-Websocket-Extensions": {"permessage-deflate; client_max_window_bits"}})
+	defer c.conn.Close()
+	if ext := c.resp.Header.Get("Sec-WebSocket-Extensions"); !strings.HasPrefix(ext, "permessage-deflate") {
+		t.Fatalf("Context.Upgrade() extensions = %q, want permessage-deflate", ext)
+	}
+
+	message := strings.Repeat("compress me ", 50)
+	compressed, err := deflate([]byte(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.writeFrame(true, true, TextMessage, compressed)
+
+	_, rsv1, opcode, payload := c.readFrame()
+	if !rsv1 || opcode != TextMessage {
+		t.Fatalf("compressed echo rsv1 = %v opcode = %d", rsv1, opcode)
+	}
+	data, _ := io.ReadAll(flate.NewReader(io.MultiReader(bytes.NewReader(payload), bytes.NewReader(deflateTail))))
+	if string(data) != message {
+		t.Errorf("compressed echo = %q, want %q", data, message)
+	}
+}
+
+func TestServer_Shutdown_webSockets(t *testing.T) {
+	server, ts, _ := newWebSocketServer(t, WebSocketConfig{})
+	defer ts.Close()
+
+	c := dialWebSocket(t, ts.URL, nil)
+	defer c.conn.Close()
+	c.writeFrame(true, false, TextMessage, []byte("hello"))
+	c.readFrame()
+
+	go server.Shutdown()
+	c.expectClose(CloseGoingAway)
+}
@@ -34,6 +34,10 @@ type (
 		interceptors Middlewares
 		// tier tree store all handlers
 		trees []*methodTree
+		// routes store all registered routes in order
+		routes []RouteInfo
+		// groups store all groups, the root group first
+		groups []*group
 
 		// Enables automatic redirection if the current route can't be matched but a
 		// handler for the path with (without) the trailing slash exists.
@@ -65,6 +69,14 @@ type (
 		// Custom OPTIONS handlers take priority over automatic replies.
 		HandleOPTIONS bool
 
+		// MaxBodyBytes limit the size of request body for all routes,
+		// 0 means no limit. Use BodyLimit to override it for a route.
+		MaxBodyBytes int64
+
+		// If enabled, BindJSON rejects unknown fields and trailing data,
+		// and decodes numbers into json.Number instead of float64.
+		StrictJSON bool
+
 		// timeout config
 		ReadTimeout       time.Duration
 		WriteTimeout      time.Duration
@@ -75,6 +87,23 @@ type (
 		notFoundHandler HandlerFunc
 		// methodNotAllowed handle method not allowed
 		methodNotAllowed HandlerFunc
+		// errorHandler render errors passed to Context.Error
+		errorHandler ErrorHandler
+		// reporter report recovered panics
+		reporter Reporter
+		// debug mode, see SetDebug
+		debug bool
+		// renderer is used by Context.HTML
+		renderer Renderer
+		// assetResolver is used by AssetURL
+		assetResolver AssetResolver
+		// webSocketConfig is used by Context.Upgrade
+		webSocketConfig WebSocketConfig
+		// webSockets track open connections to close on Shutdown
+		webSocketsMu sync.Mutex
+		webSockets   map[*WebSocket]struct{}
+		// shutdownHooks are called by Shutdown
+		shutdownHooks []func()
 	}
 )
 
@@ -153,11 +182,26 @@ func (s *Server) allowed(path, reqMethod string) (allow string) {
 func (s *Server) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 	// get context instance from pool
 	c := getContext(rw, r)
+	// the header is sent by finishResponse
+	deferHeader(c.Rw)
+	c.server = s
+	if s.debug {
+		c.SetValue(debugKey{}, &debugInfo{})
+	}
+	if s.MaxBodyBytes > 0 && r.Body != nil {
+		c.limitBody(s.MaxBodyBytes)
+	}
 
+	// path may be rewritten by redirection
+	path := r.URL.Path
 	s.handleHTTPRequest(c)
+	handlerReturned(c.Rw)
+	s.finishResponse(c, path)
 }
 
 func (s *Server) handleHTTPRequest(ctx Context) {
+	defer s.recoverPanic(ctx)
+
 	httpMethod := ctx.Req.Method
 	path := ctx.Req.URL.Path
 
@@ -176,25 +220,26 @@ func (s *Server) handleHTTPRequest(ctx Context) {
 					code = 307
 				}
 
-				if tsr && s.RedirectTrailingSlash {
+				redirectTrailingSlash := s.redirectTrailingSlash(path)
+				if tsr && redirectTrailingSlash {
 					if len(path) > 1 && path[len(path)-1] == '/' {
 						ctx.Req.URL.Path = path[:len(path)-1]
 					} else {
 						ctx.Req.URL.Path = path + "/"
 					}
-					http.Redirect(ctx.Rw, ctx.Req, ctx.Req.URL.String(), code)
+					s.redirect(ctx, path, code)
 					return
 				}
 
 				// Try to fix the request path
-				if s.RedirectFixedPath {
+				if s.redirectFixedPath(path) {
 					fixedPath, found := t.node.findCaseInsensitivePath(
 						CleanPath(path),
-						s.RedirectTrailingSlash,
+						redirectTrailingSlash,
 					)
 					if found {
 						ctx.Req.URL.Path = string(fixedPath)
-						http.Redirect(ctx.Rw, ctx.Req, ctx.Req.URL.String(), code)
+						s.redirect(ctx, path, code)
 						return
 					}
 				}
@@ -216,8 +261,12 @@ func (s *Server) handleHTTPRequest(ctx Context) {
 		if s.HandleMethodNotAllowed {
 			if allow := s.allowed(path, ctx.Req.Method); len(allow) > 0 {
 				ctx.WriteHeader("Allow", allow)
-				if s.methodNotAllowed != nil {
+				if g := s.lookupGroup(path, func(g *group) bool { return g.methodNotAllowed != nil }); g != nil {
+					g.wrap(g.methodNotAllowed)(ctx)
+				} else if s.methodNotAllowed != nil {
 					s.methodNotAllowed(ctx)
+				} else if s.statusHandler(path, StatusMethodNotAllowed) != nil {
+					ctx.WriteStatus(StatusMethodNotAllowed)
 				} else {
 					http.Error(ctx.Rw,
 						StatusText(StatusMethodNotAllowed),
@@ -232,10 +281,40 @@ func (s *Server) handleHTTPRequest(ctx Context) {
 	s.handleNotFound(ctx)
 }
 
+// redirectTrailingSlash return RedirectTrailingSlash of the group with longest base matching path
+func (s *Server) redirectTrailingSlash(path string) bool {
+	if g := s.lookupGroup(path, func(g *group) bool { return g.redirectTrailingSlash != nil }); g != nil {
+		return *g.redirectTrailingSlash
+	}
+	return s.RedirectTrailingSlash
+}
+
+// redirectFixedPath return RedirectFixedPath of the group with longest base matching path
+func (s *Server) redirectFixedPath(path string) bool {
+	if g := s.lookupGroup(path, func(g *group) bool { return g.redirectFixedPath != nil }); g != nil {
+		return *g.redirectFixedPath
+	}
+	return s.RedirectFixedPath
+}
+
+// redirect answer the request with a redirection to the rewritten request url,
+// the body is left to the status page of code if there is one
+func (s *Server) redirect(ctx Context, path string, code int) {
+	if s.statusHandler(path, code) == nil {
+		http.Redirect(ctx.Rw, ctx.Req, ctx.Req.URL.String(), code)
+		return
+	}
+	ctx.WriteHeader(HeaderLocation, ctx.Req.URL.String())
+	ctx.WriteStatus(code)
+}
+
 // Run server on addr
 func (s *Server) Run(addr string) error {
 	var err error
 	once(&s.once, func() {
+		if s.debug {
+			s.printDiagnostics(addr)
+		}
 		s.server = http.Server{Handler: s, Addr: addr, ReadTimeout: s.ReadTimeout, ReadHeaderTimeout: s.ReadHeaderTimeout, WriteTimeout: s.WriteTimeout}
 		err = s.server.ListenAndServe()
 	})
@@ -246,14 +325,28 @@ func (s *Server) Run(addr string) error {
 func (s *Server) RunTLS(addr string, certFile string, keyFile string) error {
 	var err error
 	once(&s.once, func() {
+		if s.debug {
+			s.printDiagnostics(addr)
+		}
 		s.server = http.Server{Handler: s, Addr: addr, ReadTimeout: s.ReadTimeout, ReadHeaderTimeout: s.ReadHeaderTimeout, WriteTimeout: s.WriteTimeout}
 		err = s.server.ListenAndServeTLS(certFile, keyFile)
 	})
 	return err
 }
 
-// Shutdown gracefully with deadline
+// OnShutdown register fn to be called when Shutdown start, before open
+// WebSocket connections are closed
+func (s *Server) OnShutdown(fn func()) {
+	s.shutdownHooks = append(s.shutdownHooks, fn)
+}
+
+// Shutdown gracefully with deadline, shutdown hooks are called and open
+// WebSocket connections are sent close frames
 func (s *Server) Shutdown() error {
+	for _, hook := range s.shutdownHooks {
+		hook()
+	}
+	s.closeWebSockets(CloseGoingAway, "server shutdown")
 	ctx := getContext(nil, nil)
 	if s.ShutdownDuration > 0 {
 		ctx, _ = ctx.WithDeadline(time.Now().Add(s.ShutdownDuration))